@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lonegunmanb/newres/v3/pkg"
+)
+
+// runDiff implements `newres diff`, printing how a resource's schema changed between two provider versions.
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	resourceType := flags.String("r", "", "Resource type to compare (required)")
+	flags.StringVar(resourceType, "resource-type", "", "")
+	from := flags.String("from", "", "Provider version to compare from (required)")
+	to := flags.String("to", "", "Provider version to compare to (required)")
+	format := flags.String("format", "text", "Output format, text or json")
+	providerNamespace := flags.String("provider-namespace", "", "Provider namespace (e.g., hashicorp, Azure, aliyun); discovered from the Terraform Registry if not set")
+	providerSource := flags.String("provider-source", "", "Provider source address (e.g., app.terraform.io/acme/internalcloud); overrides --provider-namespace")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(os.Stderr, "Usage: newres diff -r RESOURCE_TYPE --from VERSION --to VERSION [--format text|json]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if *resourceType == "" || *from == "" || *to == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unsupported format %q, must be text or json", *format)
+	}
+
+	cfg := pkg.Config{
+		ProviderNamespace: *providerNamespace,
+		ProviderSource:    *providerSource,
+	}
+	var diff *pkg.SchemaDiff
+	err := withChosenNamespace(&cfg, func() (err error) {
+		diff, err = pkg.DiffResourceSchema(*resourceType, cfg, *from, *to)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if *format == "text" {
+		fmt.Print(diff.Text())
+		return nil
+	}
+	output, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(output))
+	return nil
+}
@@ -1,15 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
 
-	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclwrite"
-	autofix "github.com/lonegunmanb/avmfix/pkg"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/lonegunmanb/newres/v3/pkg"
 )
 
@@ -18,6 +21,20 @@ var azapiVersionRegex = regexp.MustCompile(`^[a-zA-Z0-9.-]+(/[a-zA-Z0-9.-]+)+@[0
 func main() {
 	defer pkg.CleanupSchemaServer()
 
+	subCommands := map[string]func([]string) error{
+		"diff":    runDiff,
+		"upgrade": runUpgrade,
+	}
+	if len(os.Args) > 1 {
+		if run, ok := subCommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Printf("Error: %s\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// Parse command line flags
 	dir := flag.String("dir", "", "Directory path to store generated files (required)")
 	univar := flag.Bool("u", false, "Generate mode: UniVariable if set, MultipleVariables if not set")
@@ -25,20 +42,54 @@ func main() {
 	delimiter := flag.String("delimiter", "EOT", "Heredoc delimiter (optional)")
 	azapiResourceType := flag.String(pkg.AzApiResourceType, "", "AZAPI resource type (optional)")
 	variablePrefix := flag.String("variable-prefix", "", "Variable name prefix override (optional; empty string means no prefix in MultiVariables mode)")
-	providerNamespace := flag.String("provider-namespace", "hashicorp", "Provider namespace (e.g., hashicorp, Azure, aliyun)")
+	providerNamespace := flag.String("provider-namespace", "", "Provider namespace (e.g., hashicorp, Azure, aliyun); discovered from the Terraform Registry if not set")
+	providerSource := flag.String("provider-source", "", "Provider source address (e.g., app.terraform.io/acme/internalcloud, registry.opentofu.org/hashicorp/aws); overrides --provider-namespace")
+	name := flag.String("name", "", "Label of the generated resource block, defaults to `this`; also prefixes the default variable names")
+	dryRun := flag.Bool("dry-run", false, "Print a unified diff of the changes instead of writing them")
+	stdout := flag.Bool("stdout", false, "Print the generated code split by file instead of writing it; -dir is optional")
+	layoutName := flag.String("layout", string(pkg.DefaultLayout), "File layout: default, per-resource, avm or single")
+	docsDir := flag.String("docs-dir", "", "Local provider repository or docs directory to read resource documents from, instead of the network")
+	nullDefaults := flag.Bool("null-defaults", false, "Keep null defaults for optional arguments instead of the defaults their documents give")
+	noCrossVariableValidations := flag.Bool("no-cross-variable-validations", false, "Leave out validations between the variables of different arguments, which require Terraform 1.9 or later")
+	preventDestroy := flag.Bool("prevent-destroy", false, "Add lifecycle { prevent_destroy = true } to the generated resource")
+	forceNewReport := flag.String("force-new-report", "", "Write a JSON report of the arguments whose change replaces the resource to this file (optional)")
+	format := flag.String("format", "hcl", "Output syntax: hcl, or json for .tf.json files")
+	force := flag.Bool("force", false, "Overwrite existing resource blocks that differ from the generated ones")
 	providerVersion := flag.String("provider-version", "", "Provider version constraint (e.g., 4.39.0, ~> 4.0); mutually exclusive with --azapi-resource-type")
 	flag.StringVar(resourceType, "resource-type", "", "")
 	flag.Usage = func() {
-		_, _ = fmt.Fprintln(os.Stderr, "Usage: newres -dir [DIRECTORY] [-u] [-r RESOURCE_TYPE] [-delimiter DELIMITER] [--variable-prefix PREFIX]")
+		_, _ = fmt.Fprintln(os.Stderr, "Usage: newres -dir [DIRECTORY] [-u] [-r RESOURCE_TYPE] [-delimiter DELIMITER] [--variable-prefix PREFIX] [--name NAME] [--layout LAYOUT] [--format hcl|json] [--null-defaults] [--no-cross-variable-validations] [--prevent-destroy] [--force-new-report FILE] [--dry-run | --stdout]")
 		_, _ = fmt.Fprintln(os.Stderr, "       newres -dir [DIRECTORY] [-u] [--resource-type RESOURCE_TYPE] [-delimiter DELIMITER] [--variable-prefix PREFIX]")
+		_, _ = fmt.Fprintln(os.Stderr, "       newres diff -r RESOURCE_TYPE --from VERSION --to VERSION [--format text|json]")
+		_, _ = fmt.Fprintln(os.Stderr, "       newres upgrade -dir [DIRECTORY] -r RESOURCE_TYPE --to VERSION [--from VERSION] [--name NAME] [--variable-prefix PREFIX] [--report FILE]")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
 
-	if *dir == "" || *resourceType == "" {
+	if *dir == "" && !*stdout || *resourceType == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
+	if *dryRun && *stdout {
+		fmt.Println("Error: --dry-run and --stdout cannot be used together")
+		os.Exit(1)
+	}
+
+	layout, err := pkg.ParseFileLayout(*layoutName)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *format != "hcl" && *format != "json" {
+		fmt.Printf("Error: unknown --format %q, expected hcl or json\n", *format)
+		os.Exit(1)
+	}
+
+	if *name != "" && !hclsyntax.ValidIdentifier(*name) {
+		fmt.Printf("Error: --name %q is not a valid resource name\n", *name)
+		os.Exit(1)
+	}
 
 	variablePrefixProvided := false
 	flag.CommandLine.Visit(func(f *flag.Flag) {
@@ -78,76 +129,140 @@ func main() {
 		delimiter = &empty
 	}
 
+	cfg := pkg.Config{
+		Delimiter:                  *delimiter,
+		Mode:                       generateMode,
+		VariablePrefix:             *variablePrefix,
+		VariablePrefixSet:          variablePrefixProvided,
+		ProviderNamespace:          *providerNamespace,
+		ProviderSource:             *providerSource,
+		ProviderVersion:            *providerVersion,
+		ResourceName:               *name,
+		DocsDir:                    *docsDir,
+		NullDefaults:               *nullDefaults,
+		PreventDestroy:             *preventDestroy,
+		NoCrossVariableValidations: *noCrossVariableValidations,
+	}
+	if *dir != "" {
+		cfg.DependencyLockFile = filepath.Join(*dir, ".terraform.lock.hcl")
+	}
 	// Call GenerateResource function
-	generatedCode, err := pkg.GenerateResource(pkg.NewResourceGenerateCommand(*resourceType, pkg.Config{
-		Delimiter:         *delimiter,
-		Mode:              generateMode,
-		VariablePrefix:    *variablePrefix,
-		VariablePrefixSet: variablePrefixProvided,
-		ProviderNamespace: *providerNamespace,
-		ProviderVersion:   *providerVersion,
-	}, parameters))
+	var generatedCode string
+	err = withChosenNamespace(&cfg, func() (err error) {
+		generatedCode, err = generate(*dir, *resourceType, cfg, parameters)
+		return err
+	})
 	if err != nil {
 		fmt.Printf("Error generating resource: %s\n", err)
 		os.Exit(1)
 	}
 
-	// Split generated code into variable and resource blocks
-	variablesFile := hclwrite.NewEmptyFile()
-	resourceFile := hclwrite.NewEmptyFile()
-
-	generatedFile, diag := hclwrite.ParseConfig([]byte(generatedCode), "", hcl.InitialPos)
-	if diag.HasErrors() {
-		fmt.Printf("Error parsing generated code: %s\n", diag.Error())
-		os.Exit(1)
+	report, err := pkg.CheckDocument(pkg.NewResourceGenerateCommand(*resourceType, cfg, parameters))
+	if err == nil && !report.Empty() {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %s", report)
 	}
-
-	for _, block := range generatedFile.Body().Blocks() {
-		switch block.Type() {
-		case "variable":
-			variablesFile.Body().AppendBlock(block)
-			variablesFile.Body().AppendNewline()
-		case "resource":
-			resourceFile.Body().AppendBlock(block)
-			resourceFile.Body().AppendNewline()
+	if *forceNewReport != "" {
+		if err = writeForceNewReport(*forceNewReport, pkg.NewResourceGenerateCommand(*resourceType, cfg, parameters)); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
 		}
 	}
 
-	// Append content to variables.tf and main.tf
-	variablesPath := filepath.Join(*dir, "variables.tf")
-	mainPath := filepath.Join(*dir, "main.tf")
+	if *stdout {
+		if err = printGeneratedFiles(generatedCode, layout, *format); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *dryRun {
+		if err = previewChanges(*dir, generatedCode, layout, *format, *force); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	err = appendToFile(variablesPath, variablesFile.Bytes(), 0600)
-	if err != nil {
-		fmt.Printf("Error writing variables.tf: %s\n", err)
+	if err = writeModule(*dir, generatedCode, layout, *format, *force); err != nil {
+		fmt.Printf("Error writing generated code: %s\n", err)
 		os.Exit(1)
 	}
 
-	err = appendToFile(mainPath, resourceFile.Bytes(), 0600)
+	files, err := splitGenerated(generatedCode, layout, *format)
 	if err != nil {
-		fmt.Printf("Error writing main.tf: %s\n", err)
+		fmt.Printf("Error: %s\n", err)
 		os.Exit(1)
 	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	fmt.Printf("Successfully generated %s\n", joinNames(names))
+}
 
-	err = autofix.DirectoryAutoFix(*dir)
-	if err != nil {
-		fmt.Printf("Error autofix: %s\n", err)
-		os.Exit(1)
+// joinNames lists names for humans, e.g. "a, b and c".
+func joinNames(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
 	}
+	return fmt.Sprintf("%s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
 
-	fmt.Println("Successfully generated variables.tf and main.tf")
+// generate avoids collisions with the module in dir, if there is one.
+func generate(dir, resourceType string, cfg pkg.Config, parameters map[string]string) (string, error) {
+	if dir == "" {
+		return pkg.GenerateResource(pkg.NewResourceGenerateCommand(resourceType, cfg, parameters))
+	}
+	return pkg.GenerateForModule(dir, resourceType, cfg, parameters)
 }
 
-func appendToFile(filename string, data []byte, perm os.FileMode) error {
-	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
-	if err != nil {
+// withChosenNamespace runs run, and when the provider is ambiguous asks the user to choose its namespace, sets it on
+// cfg and runs run again. Without a terminal to ask, the ambiguity is returned as it is.
+func withChosenNamespace(cfg *pkg.Config, run func() error) error {
+	err := run()
+	var ambiguous *pkg.AmbiguousProviderError
+	if !errors.As(err, &ambiguous) || !isInteractive() {
 		return err
 	}
-	defer func() {
+	if cfg.ProviderNamespace, err = chooseNamespace(ambiguous); err != nil {
+		return err
+	}
+	return run()
+}
 
-		_ = f.Close()
-	}()
+func isInteractive() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// chooseNamespace asks the user to pick one of the candidates and remembers the choice.
+func chooseNamespace(ambiguous *pkg.AmbiguousProviderError) (string, error) {
+	fmt.Printf("Found more than one provider named %s:\n", ambiguous.ProviderType)
+	for i, c := range ambiguous.Candidates {
+		fmt.Printf("  %d. %s/%s\n", i+1, c, ambiguous.ProviderType)
+	}
+	fmt.Print("Choose one: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || i < 1 || i > len(ambiguous.Candidates) {
+		return "", fmt.Errorf("invalid choice %q", strings.TrimSpace(line))
+	}
+	namespace := ambiguous.Candidates[i-1]
+	return namespace, pkg.RememberProviderNamespace(ambiguous.ProviderType, namespace)
+}
 
-	_, err = f.Write(data)
-	return err
+// writeForceNewReport writes the JSON report of the ForceNew arguments of the generated resource to path.
+func writeForceNewReport(path string, cmd pkg.ResourceGenerateCommand) error {
+	report, err := pkg.ForceNewArguments(cmd)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0600)
 }
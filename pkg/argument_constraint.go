@@ -0,0 +1,238 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// backQuotedListPattern matches a list of back quoted names, e.g. "`a`, `b` and `c`".
+const backQuotedListPattern = "((?:`[^`]+`(?:\\s*,\\s*(?:and\\s+|or\\s+)?|\\s+(?:and|or)\\s+)?)+)"
+
+var conflictsWithRegex = regexp.MustCompile("(?i)\\b(?:conflicts with|(?:cannot|can't|can not) be (?:used|specified|set) (?:together )?with)\\s+(?:the\\s+)?" + backQuotedListPattern)
+var oneOfRegex = regexp.MustCompile("(?i)\\b(exactly |only |at most )?one of\\s+(?:the\\s+)?" + backQuotedListPattern + "\\s*(?:arguments?\\s+|blocks?\\s+)?(must|may|can|should)\\b")
+var requiredWhenRegex = regexp.MustCompile("(?i)\\brequired (?:when|if) `([^`]+)` is (?:set to )?`([^`]+)`")
+
+// argumentConstraints are the relationships with other arguments of the same block a description documents.
+type argumentConstraints struct {
+	// conflictsWith are the arguments that can't be set together with this one, e.g. "Conflicts with `x`".
+	conflictsWith []string
+	// oneOf is a group of arguments, this one included, of which the document limits how many are set.
+	oneOf *argumentGroup
+	// requiredWhen makes the argument required when another argument has a value, e.g. "Required when `kind` is
+	// `Linux`".
+	requiredWhen *requiredWhen
+}
+
+// argumentGroup is a group of arguments of which exactly one, at most one or at least one is set.
+type argumentGroup struct {
+	arguments []string
+	// operator compares the number of arguments set with one, `==`, `<=` or `>=`.
+	operator string
+}
+
+type requiredWhen struct {
+	argument string
+	value    string
+}
+
+func parseArgumentConstraints(name, desc string) argumentConstraints {
+	var c argumentConstraints
+	if m := conflictsWithRegex.FindStringSubmatch(desc); m != nil {
+		c.conflictsWith = backQuotedValues(m[1])
+	}
+	if m := oneOfRegex.FindStringSubmatch(desc); m != nil {
+		group := &argumentGroup{arguments: backQuotedValues(m[2]), operator: ">="}
+		switch strings.ToLower(strings.TrimSpace(m[1])) {
+		case "exactly":
+			group.operator = "=="
+		case "only", "at most":
+			group.operator = "<="
+		}
+		if !containsString(group.arguments, name) {
+			group.arguments = append(group.arguments, name)
+		}
+		sort.Strings(group.arguments)
+		c.oneOf = group
+	}
+	if m := requiredWhenRegex.FindStringSubmatch(desc); m != nil {
+		c.requiredWhen = &requiredWhen{argument: m[1], value: strings.Trim(m[2], `"`)}
+	}
+	return c
+}
+
+func backQuotedValues(list string) []string {
+	var values []string
+	for _, v := range backQuotedValueRegex.FindAllStringSubmatch(list, -1) {
+		values = append(values, strings.Trim(strings.TrimSpace(v[1]), `"`))
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// argumentType returns the type of the argument name of b, cty.DynamicPseudoType for nested blocks, false if b has no
+// such argument.
+func argumentType(b block, name string) (cty.Type, bool) {
+	for _, a := range b.attributes() {
+		if a.name == name && !a.computedOnly() && !a.skipAttribute() {
+			return a.AttributeType, true
+		}
+	}
+	for _, nb := range b.nestedBlocks() {
+		if nb.name == name && !nb.blockReadOnly() {
+			return cty.DynamicPseudoType, true
+		}
+	}
+	return cty.NilType, false
+}
+
+// constraintValidations returns the validations of the constraints documented for the argument name of b, ref
+// returns the expression of an argument of b. An argument counts as set when it differs from its generated default.
+// Constraints naming arguments b doesn't have, or whose defaults can't be compared, are left out, and a group or a
+// conflict documented by both arguments is validated once, by its first argument. With
+// Config.NoCrossVariableValidations the constraints of the resource's own arguments are left out in MultipleVariables
+// mode, where they refer to other variables, which requires Terraform 1.9.
+func constraintValidations(b block, name string, ref func(string) string, descriptions map[string]argumentDescription) []variableValidation {
+	if r, ok := b.(*resourceBlock); ok && r.cfg.GetMode() == MultipleVariables && r.cfg.NoCrossVariableValidations {
+		return nil
+	}
+	d, ok := describe(descriptions, documentPath(b, name))
+	if !ok {
+		return nil
+	}
+	var validations []variableValidation
+	for _, other := range d.conflictsWith {
+		if _, ok := argumentType(b, other); !ok || other == name || (other < name && documentsConflict(b, other, name, descriptions)) {
+			continue
+		}
+		unset, ok := unsetValue(b, name, descriptions)
+		otherUnset, otherOk := unsetValue(b, other, descriptions)
+		if !ok || !otherOk {
+			continue
+		}
+		validations = append(validations, variableValidation{
+			condition:    fmt.Sprintf("%s == %s || %s == %s", ref(name), unset, ref(other), otherUnset),
+			errorMessage: fmt.Sprintf("`%s` conflicts with `%s`, only one of them can be set.", documentPath(b, name), documentPath(b, other)),
+		})
+	}
+	if g := d.oneOf; g != nil && allArguments(b, g.arguments) && documentsGroupFirst(b, name, g, descriptions) {
+		var refs, sets, paths []string
+		defaults, comparable := false, true
+		for _, a := range g.arguments {
+			unset, ok := unsetValue(b, a, descriptions)
+			comparable = comparable && ok
+			defaults = defaults || unset != "null"
+			refs = append(refs, ref(a))
+			sets = append(sets, fmt.Sprintf("%s != %s", ref(a), unset))
+			paths = append(paths, fmt.Sprintf("`%s`", documentPath(b, a)))
+		}
+		quantity := map[string]string{"==": "Exactly one", "<=": "At most one", ">=": "At least one"}[g.operator]
+		condition := fmt.Sprintf("length([for argument in [%s] : argument if argument != null]) %s 1", strings.Join(refs, ", "), g.operator)
+		if defaults {
+			condition = fmt.Sprintf("length([for set in [%s] : set if set]) %s 1", strings.Join(sets, ", "), g.operator)
+		}
+		if comparable {
+			validations = append(validations, variableValidation{
+				condition:    condition,
+				errorMessage: fmt.Sprintf("%s of %s must be set.", quantity, strings.Join(paths, ", ")),
+			})
+		}
+	}
+	if rw := d.requiredWhen; rw != nil {
+		t, ok := argumentType(b, rw.argument)
+		if !ok || t == cty.DynamicPseudoType {
+			return validations
+		}
+		value, ok := documentedLiteral(rw.value, t)
+		if !ok {
+			return validations
+		}
+		literal := strings.TrimSpace(string(hclwrite.TokensForValue(value).Bytes()))
+		validations = append(validations, variableValidation{
+			condition:    fmt.Sprintf("%s != %s || %s != null", ref(rw.argument), literal, ref(name)),
+			errorMessage: fmt.Sprintf("`%s` is required when `%s` is %s.", documentPath(b, name), documentPath(b, rw.argument), literal),
+		})
+	}
+	return validations
+}
+
+// documentsConflict reports whether the description of the argument name of b documents a conflict with other.
+func documentsConflict(b block, name, other string, descriptions map[string]argumentDescription) bool {
+	d, ok := describe(descriptions, documentPath(b, name))
+	return ok && containsString(d.conflictsWith, other)
+}
+
+// unsetValue returns the literal the argument name of b has when it isn't set, its generated default or `null`, false
+// when the default isn't a primitive value, which can't be compared with a literal.
+func unsetValue(b block, name string, descriptions map[string]argumentDescription) (string, bool) {
+	if r := rootBlock(b); r != nil {
+		descriptions = r.defaults(descriptions)
+	}
+	for _, a := range b.attributes() {
+		if a.name != name || !a.Optional {
+			continue
+		}
+		v, ok := documentedDefault(descriptions, documentPath(b, name), a.AttributeType)
+		if !ok {
+			break
+		}
+		if !v.Type().IsPrimitiveType() {
+			return "", false
+		}
+		return strings.TrimSpace(string(hclwrite.TokensForValue(v).Bytes())), true
+	}
+	return "null", true
+}
+
+// rootBlock returns the resource block b belongs to, nil if it belongs to none.
+func rootBlock(b block) *resourceBlock {
+	for {
+		switch v := b.(type) {
+		case *resourceBlock:
+			return v
+		case *nestedBlock:
+			b = v.parent
+		default:
+			return nil
+		}
+	}
+}
+
+// documentsGroupFirst reports whether name is the first argument of g whose description documents g, so the group is
+// validated once however many of its arguments document it.
+func documentsGroupFirst(b block, name string, g *argumentGroup, descriptions map[string]argumentDescription) bool {
+	for _, a := range g.arguments {
+		if a == name {
+			return true
+		}
+		if d, ok := describe(descriptions, documentPath(b, a)); ok && d.oneOf != nil && sameStrings(d.oneOf.arguments, g.arguments) {
+			return false
+		}
+	}
+	return false
+}
+
+func sameStrings(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+func allArguments(b block, names []string) bool {
+	for _, n := range names {
+		if _, ok := argumentType(b, n); !ok {
+			return false
+		}
+	}
+	return true
+}
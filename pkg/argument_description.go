@@ -1,7 +1,167 @@
 package pkg
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// defaultValueRegex matches the default value a description gives in backquotes, e.g. "Defaults to `true`.".
+var defaultValueRegex = regexp.MustCompile("(?i)\\bdefaults? (?:to|is|value is) `([^`]+)`")
+
+// possibleValuesRegex matches the list of values a description allows, e.g. "Possible values are `Standard` and
+// `Premium`." or "Valid values: `secrets`.".
+var possibleValuesRegex = regexp.MustCompile("(?i)\\b(?:possible|valid|allowed|accepted|supported) values(?: are| include| is)?:?\\s*((?:`[^`]+`(?:\\s*,\\s*(?:and\\s+|or\\s+)?|\\s+(?:and|or)\\s+)?)+)")
+var backQuotedValueRegex = regexp.MustCompile("`([^`]+)`")
+
+// forceNewRegex matches the sentence marking arguments whose change replaces the resource, e.g. "Changing this forces a
+// new resource to be created." or "Changing this value will force a new cluster to be created.".
+var forceNewRegex = regexp.MustCompile(`(?i)\bchanging this(?: \w+)? (?:forces|will force) a new\b`)
+
+// argumentDescription is the documentation of one argument. Descriptions are keyed by the argument's path from the
+// resource, e.g. `default_node_pool.linux_os_config.swap_file_size_mb`, or a shorter path when the document doesn't
+// tell where its block is nested.
 type argumentDescription struct {
-	name         string
-	desc         string
+	name string
+	desc string
+	// defaultValue is the default the description documents, as written in the document.
 	defaultValue *string
+	// possibleValues are the only values the description allows, unquoted.
+	possibleValues []string
+	// forceNew is set when changing the argument replaces the resource, ForceNew in the provider's schema.
+	forceNew bool
+	argumentConstraints
+}
+
+// withDocumentedValues sets the default values, possible values, ForceNew marks and constraints descriptions document.
+func withDocumentedValues(descriptions map[string]argumentDescription) map[string]argumentDescription {
+	for key, d := range descriptions {
+		if m := defaultValueRegex.FindStringSubmatch(d.desc); m != nil {
+			value := strings.TrimSpace(m[1])
+			d.defaultValue = &value
+		}
+		if m := possibleValuesRegex.FindStringSubmatch(d.desc); m != nil {
+			for _, v := range backQuotedValueRegex.FindAllStringSubmatch(m[1], -1) {
+				d.possibleValues = append(d.possibleValues, strings.Trim(strings.TrimSpace(v[1]), `"`))
+			}
+		}
+		d.forceNew = forceNewRegex.MatchString(d.desc)
+		d.argumentConstraints = parseArgumentConstraints(d.name, d.desc)
+		descriptions[key] = d
+	}
+	return descriptions
+}
+
+// documentedDefault returns the documented default of the argument at path converted to t, false when there's none,
+// or it isn't a value of t.
+func documentedDefault(descriptions map[string]argumentDescription, path string, t cty.Type) (cty.Value, bool) {
+	d, ok := describe(descriptions, path)
+	if !ok || d.defaultValue == nil {
+		return cty.NilVal, false
+	}
+	return documentedLiteral(*d.defaultValue, t)
+}
+
+// documentedLiteral converts a value written in a document to t, false if it isn't a value of t.
+func documentedLiteral(literal string, t cty.Type) (cty.Value, bool) {
+	var v cty.Value
+	expr, diag := hclsyntax.ParseExpression([]byte(literal), "", hcl.InitialPos)
+	if !diag.HasErrors() {
+		v, diag = expr.Value(nil)
+	}
+	if diag.HasErrors() {
+		if t != cty.String {
+			return cty.NilVal, false
+		}
+		// documents often leave string values unquoted, e.g. `Standard`
+		v = cty.StringVal(literal)
+	}
+	v, err := convert.Convert(v, t)
+	if err != nil || v.IsNull() || !v.IsWhollyKnown() {
+		return cty.NilVal, false
+	}
+	return v, true
+}
+
+// documentPath returns the path of the argument name of b, relative to the resource, like the keys of descriptions.
+func documentPath(b block, name string) string {
+	nb, ok := b.(*nestedBlock)
+	if !ok {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", documentPath(nb.parent, nb.name), name)
+}
+
+// describe looks the argument at path up in descriptions. Without a description at the full path, it falls back to
+// the longest documented suffix of path, but only when no other documented argument shares that suffix. Only keys of
+// nested arguments are candidates, a root argument never describes a nested one of the same name.
+func describe(descriptions map[string]argumentDescription, path string) (argumentDescription, bool) {
+	if d, ok := descriptions[path]; ok {
+		return d, true
+	}
+	segments := strings.Split(path, ".")
+	for i := 1; i < len(segments); i++ {
+		suffix := strings.Join(segments[i:], ".")
+		var candidates []string
+		for key := range descriptions {
+			if !strings.Contains(key, ".") {
+				continue
+			}
+			if key == suffix || strings.HasSuffix(key, "."+suffix) {
+				candidates = append(candidates, key)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		if len(candidates) == 1 && strings.HasSuffix(path, "."+candidates[0]) {
+			return descriptions[candidates[0]], true
+		}
+		return argumentDescription{}, false
+	}
+	return argumentDescription{}, false
+}
+
+// qualifyDocumentPaths rewrites the keys of arguments whose block the document only names, e.g.
+// `linux_os_config.swap_file_size_mb`, to full paths like `default_node_pool.linux_os_config.swap_file_size_mb`, using
+// the document's own hierarchy: a block documented as an argument of exactly one other block is nested in that block.
+// Blocks documented as arguments of several blocks keep their short keys.
+func qualifyDocumentPaths(descriptions map[string]argumentDescription) map[string]argumentDescription {
+	for i := 0; i < len(descriptions); i++ {
+		changed := false
+		keys := sortedKeys(descriptions)
+		for _, key := range keys {
+			first, rest, nested := strings.Cut(key, ".")
+			if !nested {
+				continue
+			}
+			if _, root := descriptions[first]; root {
+				continue
+			}
+			var parents []string
+			for _, k := range keys {
+				if strings.HasSuffix(k, "."+first) && !strings.HasPrefix(key, k+".") {
+					parents = append(parents, k)
+				}
+			}
+			if len(parents) != 1 {
+				continue
+			}
+			qualified := fmt.Sprintf("%s.%s", parents[0], rest)
+			if _, exists := descriptions[qualified]; !exists {
+				descriptions[qualified] = descriptions[key]
+			}
+			delete(descriptions, key)
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+	return descriptions
 }
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDescribe_FullPathFirst(t *testing.T) {
+	descriptions := map[string]argumentDescription{
+		"ingress.name":                {name: "name", desc: "ingress"},
+		"template.container.env.name": {name: "name", desc: "env"},
+		"name":                        {name: "name", desc: "resource"},
+	}
+	d, ok := describe(descriptions, "template.container.env.name")
+	assert.True(t, ok)
+	assert.Equal(t, "env", d.desc)
+	d, ok = describe(descriptions, "name")
+	assert.True(t, ok)
+	assert.Equal(t, "resource", d.desc)
+}
+
+func TestDescribe_FallbackOnlyWhenUnambiguous(t *testing.T) {
+	descriptions := map[string]argumentDescription{
+		"name":                    {name: "name", desc: "resource"},
+		"header.name":             {name: "name", desc: "header"},
+		"liveness_probe.port":     {name: "port", desc: "liveness"},
+		"readiness_probe.port":    {name: "port", desc: "readiness"},
+		"sysctl_config.fs_nr_max": {name: "fs_nr_max", desc: "sysctl"},
+	}
+	d, ok := describe(descriptions, "template.container.liveness_probe.header.name")
+	assert.True(t, ok)
+	assert.Equal(t, "header", d.desc)
+	_, ok = describe(descriptions, "template.container.startup_probe.port")
+	assert.False(t, ok, "port is documented for several blocks")
+	_, ok = describe(descriptions, "default_node_pool.name")
+	assert.False(t, ok)
+	_, ok = describe(map[string]argumentDescription{
+		"name": {name: "name", desc: "resource"},
+	}, "default_node_pool.name")
+	assert.False(t, ok, "the resource's own name doesn't describe a nested one")
+}
+
+func TestQualifyDocumentPaths(t *testing.T) {
+	descriptions := qualifyDocumentPaths(map[string]argumentDescription{
+		"default_node_pool":                 {name: "default_node_pool"},
+		"default_node_pool.linux_os_config": {name: "linux_os_config"},
+		"linux_os_config.sysctl_config":     {name: "sysctl_config"},
+		"sysctl_config.fs_file_max":         {name: "fs_file_max"},
+		"liveness_probe.header":             {name: "header"},
+		"readiness_probe.header":            {name: "header"},
+		"header.name":                       {name: "name"},
+		"timeouts.create":                   {name: "create"},
+	})
+	assert.Equal(t, []string{
+		"default_node_pool",
+		"default_node_pool.linux_os_config",
+		"default_node_pool.linux_os_config.sysctl_config",
+		"default_node_pool.linux_os_config.sysctl_config.fs_file_max",
+		"header.name",
+		"liveness_probe.header",
+		"readiness_probe.header",
+		"timeouts.create",
+	}, sortedKeys(descriptions))
+}
+
+func TestDocumentedDefault(t *testing.T) {
+	cases := []struct {
+		desc     string
+		t        cty.Type
+		expected cty.Value
+	}{
+		{desc: "(Optional) Is it enabled? Defaults to `true`.", t: cty.Bool, expected: cty.True},
+		{desc: "(Optional) The interval. Defaults to `30`.", t: cty.Number, expected: cty.NumberIntVal(30)},
+		{desc: "(Optional) The tier. Defaults to `Standard`.", t: cty.String, expected: cty.StringVal("Standard")},
+		{desc: "(Optional) The policy. Defaults to `\"none\"` when unset.", t: cty.String, expected: cty.StringVal("none")},
+		{desc: "(Optional) The zones. Defaults to `[\"1\", \"2\"]`.", t: cty.List(cty.String), expected: cty.ListVal([]cty.Value{cty.StringVal("1"), cty.StringVal("2")})},
+		{desc: "(Optional) The version. The default value is `1.2.3`.", t: cty.String, expected: cty.StringVal("1.2.3")},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			descriptions := withDocumentedValues(map[string]argumentDescription{"arg": {name: "arg", desc: c.desc}})
+			v, ok := documentedDefault(descriptions, "arg", c.t)
+			require.True(t, ok)
+			assert.True(t, c.expected.RawEquals(v), v.GoString())
+		})
+	}
+}
+
+func TestDocumentedDefault_NoDefault(t *testing.T) {
+	descriptions := withDocumentedValues(map[string]argumentDescription{
+		"interval": {name: "interval", desc: "(Optional) The interval. Defaults to `30s`."},
+		"size":     {name: "size", desc: "(Optional) The size."},
+		"nothing":  {name: "nothing", desc: "(Optional) Nothing. Defaults to `null`."},
+	})
+	_, ok := documentedDefault(descriptions, "interval", cty.Number)
+	assert.False(t, ok)
+	_, ok = documentedDefault(descriptions, "size", cty.String)
+	assert.False(t, ok)
+	_, ok = documentedDefault(descriptions, "nothing", cty.String)
+	assert.False(t, ok)
+	_, ok = documentedDefault(nil, "size", cty.String)
+	assert.False(t, ok)
+}
+
+func TestGenerateResource_DocumentedDefaults(t *testing.T) {
+	previous := content
+	content = func(string, Config) (string, error) {
+		return `## Arguments Reference
+
+* ` + "`name`" + ` - (Required) The name.
+
+* ` + "`sku`" + ` - (Optional) The SKU. Defaults to ` + "`Standard`" + `.
+
+* ` + "`network`" + ` - (Optional) A network block.
+
+A ` + "`network`" + ` block supports the following:
+
+* ` + "`dns`" + ` - (Optional) The DNS server. Defaults to ` + "`168.63.129.16`" + `.
+
+## Attributes Reference
+`, nil
+	}
+	t.Cleanup(func() {
+		content = previous
+	})
+	cfg := Config{SchemaSource: upgradeTestSchemas, ProviderVersion: "2.0.0"}
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", cfg, nil))
+	require.NoError(t, err)
+	assert.Regexp(t, `variable "resource_sku" {[^}]+default\s+= "Standard"`, generated)
+	assert.Regexp(t, `dns\s+= optional\(string, "168.63.129.16"\)`, generated)
+
+	cfg.Mode = UniVariable
+	generated, err = GenerateResource(NewResourceGenerateCommand("fake_resource", cfg, nil))
+	require.NoError(t, err)
+	assert.Regexp(t, `sku\s+= optional\(string, "Standard"\)`, generated)
+
+	cfg.NullDefaults = true
+	generated, err = GenerateResource(NewResourceGenerateCommand("fake_resource", cfg, nil))
+	require.NoError(t, err)
+	assert.NotContains(t, generated, `"Standard")`)
+	assert.Regexp(t, `sku\s+= optional\(string\)`, generated)
+}
+
+func TestWithDocumentedValues_PossibleValues(t *testing.T) {
+	cases := map[string][]string{
+		"(Optional) The SKU. Possible values are `Standard` and `Premium`.":                                      {"Standard", "Premium"},
+		"(Required) Type of probe. Possible values are `TCP`, `HTTP`, and `HTTPS`.":                              {"TCP", "HTTP", "HTTPS"},
+		"(Required) List of strings with resources to be encrypted. Valid values: `secrets`.":                    {"secrets"},
+		"(Optional) The policy. One of `\"none\"` or `\"static\"`. Valid values are `\"none\"` or `\"static\"`.": {"none", "static"},
+		"(Optional) The port. Possible values are between `1` and `65535`.":                                      nil,
+		"(Optional) The name.": nil,
+	}
+	for desc, expected := range cases {
+		d := withDocumentedValues(map[string]argumentDescription{"arg": {name: "arg", desc: desc}})["arg"]
+		assert.Equal(t, expected, d.possibleValues, desc)
+	}
+}
@@ -40,27 +40,21 @@ func restoreToNestedBlockSchema(attr *tfjson.SchemaAttribute) *tfjson.SchemaBloc
 		Attributes:   map[string]*tfjson.SchemaAttribute{},
 		NestedBlocks: map[string]*tfjson.SchemaBlockType{},
 	}
-	var fields map[string]cty.Type
-	if attributeType.IsObjectType() {
-		fields = attributeType.AttributeTypes()
-	} else {
-		fields = attributeType.ElementType().AttributeTypes()
-	}
-	for s, t := range fields {
+	objectType := restoredObjectType(attributeType)
+	for s, t := range objectType.AttributeTypes() {
+		optional := objectType.AttributeOptional(s)
 		if t.IsPrimitiveType() || t == cty.DynamicPseudoType || (t.IsCollectionType() && isSimpleElementType(t)) {
-			newAttr := &tfjson.SchemaAttribute{
+			schemaBlock.Attributes[s] = &tfjson.SchemaAttribute{
 				AttributeType: t,
-				Optional:      attributeType.IsObjectType() && attributeType.AttributeOptional(s),
+				Optional:      optional,
+				Required:      !optional,
 			}
-			newAttr.Required = !newAttr.Optional
-			schemaBlock.Attributes[s] = newAttr
 		} else {
-			newNb := restoreToNestedBlockSchema(&tfjson.SchemaAttribute{
+			schemaBlock.NestedBlocks[s] = restoreToNestedBlockSchema(&tfjson.SchemaAttribute{
 				AttributeType: t,
-				Optional:      attributeType.IsObjectType() && attributeType.AttributeOptional(s),
-				Required:      !attributeType.IsObjectType() || !attributeType.AttributeOptional(s),
+				Optional:      optional,
+				Required:      !optional,
 			})
-			schemaBlock.NestedBlocks[s] = newNb
 		}
 	}
 	nb := &tfjson.SchemaBlockType{
@@ -72,6 +66,15 @@ func restoreToNestedBlockSchema(attr *tfjson.SchemaAttribute) *tfjson.SchemaBloc
 	return nb
 }
 
+// restoredObjectType returns the object type of an attribute restored to a nested block, the type itself or the element
+// type of a collection of objects.
+func restoredObjectType(t cty.Type) cty.Type {
+	if t.IsObjectType() {
+		return t
+	}
+	return t.ElementType()
+}
+
 func isSimpleElementType(t cty.Type) bool {
 	elementType := t.ElementType()
 	return elementType.IsPrimitiveType() || elementType == cty.DynamicPseudoType
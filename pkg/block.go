@@ -7,6 +7,7 @@ import (
 	"github.com/ahmetb/go-linq/v3"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type block interface {
@@ -20,6 +21,12 @@ type block interface {
 }
 
 func newAttribute(parent block, name string, schema *tfjson.SchemaAttribute) *attribute {
+	if schema.AttributeNestedType != nil && schema.AttributeType == cty.NilType {
+		// nested attributes are assigned as a whole, like any other attribute of their type
+		nested := *schema
+		nested.AttributeType = nestedAttributeType(schema.AttributeNestedType)
+		schema = &nested
+	}
 	return &attribute{
 		SchemaAttribute: schema,
 		name:            name,
@@ -40,15 +47,24 @@ func normalizeBlockContents(b block) ([]*attribute, []*nestedBlock) {
 			return
 		}
 		at := attr.AttributeType
+		if attr.AttributeNestedType != nil {
+			attrs = append(attrs, attr)
+			return
+		}
 		// Some nested blocks are marked as attributes https://github.com/hashicorp/terraform-provider-azurerm/blob/v3.62.1/internal/services/containers/container_group_resource.go#L187C43-L187C43
 		if at.IsObjectType() || (at.IsCollectionType() && at.ElementType().IsObjectType()) {
-			nb := newNestedBlock(b, name, restoreToNestedBlockSchema(attr.SchemaAttribute))
+			nb := newRestoredNestedBlock(b, name, restoreToNestedBlockSchema(attr.SchemaAttribute), at)
 			nbs = append(nbs, nb)
 			return
 		}
 		attrs = append(attrs, attr)
 	})
 	for name, nb := range b.schemaBlock().NestedBlocks {
+		if parent, ok := b.(*nestedBlock); ok && parent.restoredType != cty.NilType {
+			// blocks within a restored block are restored too
+			nbs = append(nbs, newRestoredNestedBlock(b, name, nb, restoredObjectType(parent.restoredType).AttributeType(name)))
+			continue
+		}
 		nbs = append(nbs, newNestedBlock(b, name, nb))
 	}
 	linq.From(nbs).OrderBy(func(i interface{}) interface{} {
@@ -57,16 +73,38 @@ func normalizeBlockContents(b block) ([]*attribute, []*nestedBlock) {
 	return attrs, nbs
 }
 
-func generateVariableType(b block, rootType bool) string {
+// singleObject reports whether the variable of b is an object, not a collection of objects.
+func singleObject(b block) bool {
+	nb, isNestedBlock := b.(*nestedBlock)
+	if !isNestedBlock {
+		return b.maxItems() == 1
+	}
+	switch nb.NestingMode() {
+	case tfjson.SchemaNestingModeSingle, tfjson.SchemaNestingModeGroup:
+		return true
+	case tfjson.SchemaNestingModeMap:
+		return false
+	}
+	return nb.maxItems() == 1
+}
+
+// generateVariableType returns the object type of b's variable. Optional attributes with a default in defaults get it
+// as the default of their `optional` modifier.
+func generateVariableType(b block, rootType bool, defaults map[string]argumentDescription) string {
 	var sb strings.Builder
 	nb, isNestedBlock := b.(*nestedBlock)
 	closeToken := "})"
-	if b.maxItems() == 1 || isNestedBlock && nb.NestingMode() == tfjson.SchemaNestingModeSingle {
+	if singleObject(b) {
 		sb.WriteString("object({\n")
 	} else {
 		collection := "set(object({\n"
-		if isNestedBlock && nb.NestingMode() == tfjson.SchemaNestingModeList {
-			collection = "list(object({\n"
+		if isNestedBlock {
+			switch nb.NestingMode() {
+			case tfjson.SchemaNestingModeList:
+				collection = "list(object({\n"
+			case tfjson.SchemaNestingModeMap:
+				collection = "map(object({\n"
+			}
 		}
 		closeToken = "}))"
 		sb.WriteString(collection)
@@ -79,7 +117,9 @@ func generateVariableType(b block, rootType bool) string {
 			continue
 		}
 		attrType := ctyTypeToVariableTypeString(attr.AttributeType)
-		if attr.Optional {
+		if v, ok := documentedDefault(defaults, documentPath(b, name), attr.AttributeType); ok && attr.Optional {
+			sb.WriteString(fmt.Sprintf("  %s = optional(%s, %s)\n", name, attrType, hclwrite.TokensForValue(v).Bytes()))
+		} else if attr.Optional {
 			sb.WriteString(fmt.Sprintf("  %s = optional(%s)\n", name, attrType))
 		} else {
 			sb.WriteString(fmt.Sprintf("  %s = %s\n", name, attrType))
@@ -89,14 +129,22 @@ func generateVariableType(b block, rootType bool) string {
 	for _, s := range b.nestedBlocks() {
 		name := s.name
 		if !s.blockReadOnly() {
-			sb.WriteString(fmt.Sprintf("  %s = %s\n", name, generateVariableType(s, false)))
+			sb.WriteString(fmt.Sprintf("  %s = %s\n", name, generateVariableType(s, false, defaults)))
 		}
 	}
 
 	sb.WriteString(closeToken)
 
 	t := sb.String()
-	if !rootType && b.minItems() < 1 {
+	if rootType {
+		return t
+	}
+	if isNestedBlock && nb.alwaysPresent() {
+		// a group block is there even when it's not configured, its fields are null then
+		if !requiresArguments(nb) {
+			t = fmt.Sprintf("optional(%s, {})", t)
+		}
+	} else if b.minItems() < 1 {
 		t = fmt.Sprintf("optional(%s)", t)
 	}
 	return t
@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// cliConfig holds the parts of the Terraform CLI configuration (`.terraformrc`, `terraform.rc` or `TF_CLI_CONFIG_FILE`)
+// newres cares about.
+type cliConfig struct {
+	credentials map[string]string
+	// providerInstallation is nil when the config has no `provider_installation` block,
+	// in which case providers are installed directly from their registries.
+	providerInstallation []installationMethod
+}
+
+// cliConfigFile is decoded with HCL 1, which is what Terraform CLI uses for its configuration file.
+type cliConfigFile struct {
+	Credentials map[string]map[string]any `hcl:"credentials"`
+}
+
+type credentialsFile struct {
+	Credentials map[string]struct {
+		Token string `json:"token"`
+	} `json:"credentials"`
+}
+
+// loadCliConfig reads the Terraform CLI configuration file together with the credentials file written by `terraform login`.
+// Missing files are not an error.
+func loadCliConfig() (*cliConfig, error) {
+	cfg := &cliConfig{
+		credentials: make(map[string]string),
+	}
+	if dir := terraformConfigDir(); dir != "" {
+		if err := cfg.loadCredentialsFile(filepath.Join(dir, "credentials.tfrc.json")); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadConfigFile(cliConfigFilePath()); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// token returns the API token for a registry host. `TF_TOKEN_<host>` environment variables take precedence over
+// credentials from configuration files, the same as in Terraform CLI.
+func (c *cliConfig) token(hostname string) string {
+	if token, ok := os.LookupEnv(credentialsEnvName(hostname)); ok {
+		return token
+	}
+	return c.credentials[strings.ToLower(hostname)]
+}
+
+func (c *cliConfig) loadConfigFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	src, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to read Terraform CLI config %s: %w", path, err)
+	}
+	f, err := hcl.ParseBytes(src)
+	if err != nil {
+		return fmt.Errorf("failed to parse Terraform CLI config %s: %w", path, err)
+	}
+	var file cliConfigFile
+	if err = hcl.DecodeObject(&file, f); err != nil {
+		return fmt.Errorf("failed to decode Terraform CLI config %s: %w", path, err)
+	}
+	for host, credentials := range file.Credentials {
+		if token, ok := credentials["token"].(string); ok {
+			c.credentials[strings.ToLower(host)] = token
+		}
+	}
+	root, ok := f.Node.(*ast.ObjectList)
+	if !ok {
+		return nil
+	}
+	blocks := root.Filter("provider_installation").Items
+	if len(blocks) > 1 {
+		return fmt.Errorf("found more than one provider_installation block in Terraform CLI config %s", path)
+	}
+	for _, b := range blocks {
+		if c.providerInstallation, err = decodeProviderInstallation(b); err != nil {
+			return fmt.Errorf("failed to decode provider_installation in Terraform CLI config %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// providerSource returns where addr should be installed from according to the config.
+func (c *cliConfig) providerSource(addr providerAddress) (providerSource, error) {
+	if c.providerInstallation == nil {
+		return newRegistryClient(addr.hostname)
+	}
+	return newInstallationSource(addr, c.providerInstallation, c)
+}
+
+// installsDirectly reports whether providers may be installed directly from their registries, which is the case
+// without a `provider_installation` block or with a `direct` method in it.
+func (c *cliConfig) installsDirectly() bool {
+	if c.providerInstallation == nil {
+		return true
+	}
+	for _, m := range c.providerInstallation {
+		if m.kind == directInstallation {
+			return true
+		}
+	}
+	return false
+}
+
+// mirroredNamespaces returns the namespaces the filesystem mirrors of the config have providerType of the Terraform
+// Registry in, sorted.
+func (c *cliConfig) mirroredNamespaces(providerType string) []string {
+	seen := make(map[string]bool)
+	for _, m := range c.providerInstallation {
+		if m.kind != filesystemMirrorInstallation {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(m.location, defaultRegistryHost))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			addr := providerAddress{hostname: defaultRegistryHost, namespace: e.Name(), providerType: providerType}
+			if !e.IsDir() || !m.matches(addr) {
+				continue
+			}
+			if _, err = os.Stat(filesystemMirror{dir: m.location}.providerDir(addr)); err == nil {
+				seen[e.Name()] = true
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func (c *cliConfig) loadCredentialsFile(path string) error {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file %s: %w", path, err)
+	}
+	var file credentialsFile
+	if err = json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to decode credentials file %s: %w", path, err)
+	}
+	for host, credentials := range file.Credentials {
+		c.credentials[strings.ToLower(host)] = credentials.Token
+	}
+	return nil
+}
+
+func cliConfigFilePath() string {
+	if path := os.Getenv("TF_CLI_CONFIG_FILE"); path != "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "terraform.rc")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".terraformrc")
+}
+
+func terraformConfigDir() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "terraform.d")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".terraform.d")
+}
+
+// credentialsEnvName encodes a hostname the way Terraform expects it in `TF_TOKEN_` variables:
+// dots become underscores and dashes become double underscores.
+func credentialsEnvName(hostname string) string {
+	name := strings.ReplaceAll(strings.ToLower(hostname), "-", "__")
+	return "TF_TOKEN_" + strings.ReplaceAll(name, ".", "_")
+}
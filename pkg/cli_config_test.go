@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setTestCliConfig(t *testing.T, config string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("APPDATA", home)
+	path := filepath.Join(home, "test.tfrc")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0600))
+	t.Setenv("TF_CLI_CONFIG_FILE", path)
+}
+
+func TestCliConfig_CredentialsBlock(t *testing.T) {
+	setTestCliConfig(t, `
+plugin_cache_dir = "/tmp/plugins"
+
+credentials "app.terraform.io" {
+  token = "secret"
+}
+`)
+	cfg, err := loadCliConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", cfg.token("App.Terraform.io"))
+	assert.Equal(t, "", cfg.token("registry.terraform.io"))
+}
+
+func TestCliConfig_CredentialsFile(t *testing.T) {
+	setTestCliConfig(t, "")
+	dir := terraformConfigDir()
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "credentials.tfrc.json"), []byte(`{"credentials":{"app.terraform.io":{"token":"from-login"}}}`), 0600))
+	cfg, err := loadCliConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "from-login", cfg.token("app.terraform.io"))
+}
+
+func TestCliConfig_EnvironmentTokenTakesPrecedence(t *testing.T) {
+	setTestCliConfig(t, `
+credentials "my-registry.example.com" {
+  token = "secret"
+}
+`)
+	t.Setenv("TF_TOKEN_my__registry_example_com", "from-env")
+	cfg, err := loadCliConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.token("my-registry.example.com"))
+}
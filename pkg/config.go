@@ -15,11 +15,36 @@ type Config struct {
 	VariablePrefix    string
 	VariablePrefixSet bool
 	// ProviderNamespace is the provider namespace used for dynamic schema retrieval
-	// (e.g., "hashicorp", "Azure", "aliyun"). Defaults to "hashicorp" if empty.
+	// (e.g., "hashicorp", "Azure", "aliyun"). If empty, it's discovered from the Terraform Registry by provider type.
 	ProviderNamespace string
-	// ProviderVersion is the provider version constraint (e.g., "4.39.0", "~> 4.0").
-	// If empty, the latest version is fetched from the Terraform Registry.
+	// ProviderSource is a provider source address in the `[HOSTNAME/]NAMESPACE/TYPE` form used by `required_providers`
+	// (e.g., "app.terraform.io/acme/internalcloud"). It takes precedence over ProviderNamespace when set.
+	ProviderSource string
+	// ProviderVersion is the provider version constraint (e.g., "4.39.0", "~> 4.0"). It's resolved to one exact version,
+	// the version DependencyLockFile locks if it satisfies the constraint, or the latest matching one on the registry,
+	// which both the schema and the documentation come from. If empty, any version matches.
 	ProviderVersion string
+	// DependencyLockFile is the path of a `.terraform.lock.hcl`. When it locks the provider at the version in use,
+	// the downloaded package must match one of its hashes. Ignored if empty or missing.
+	DependencyLockFile string
+	// ResourceName is the label of the generated resource block, defaults to "this". When set, the default variable
+	// prefix becomes `<ResourceName>_<resource type without vendor>`.
+	ResourceName string
+	// DocsDir is a local provider repository, or its docs directory, to read resource documents from instead of the
+	// network, laid out like `website/docs/r/<name>.html.markdown` or tfplugindocs' `docs/resources/<name>.md`.
+	DocsDir string
+	// NullDefaults keeps `default = null` for optional arguments instead of the defaults their documents give, so the
+	// provider's own defaults apply.
+	NullDefaults bool
+	// NoCrossVariableValidations leaves out the validations of documented constraints between arguments in
+	// MultipleVariables mode, which refer to other variables and so require Terraform 1.9 or later.
+	NoCrossVariableValidations bool
+	// PreventDestroy adds `lifecycle { prevent_destroy = true }` to the generated resource, so a change of a ForceNew
+	// argument fails the plan instead of replacing the resource. Terraform only accepts a literal there, not a variable.
+	PreventDestroy bool
+	// SchemaSource provides resource schemas, defaults to NewTfPluginSchemaSource if nil. Documents come from it too
+	// when it's a DocumentSource, so a source that isn't, like NewMapSchemaSource, generates without network access.
+	SchemaSource SchemaSource
 }
 
 func (c Config) GetDelimiter() string {
@@ -36,6 +61,13 @@ func (c Config) GetMode() GenerateMode {
 	return c.Mode
 }
 
+func (c Config) GetSchemaSource() SchemaSource {
+	if c.SchemaSource == nil {
+		return NewTfPluginSchemaSource()
+	}
+	return c.SchemaSource
+}
+
 func (c Config) GetVariablePrefix(defaultPrefix string) string {
 	if c.VariablePrefixSet {
 		// honor explicit value, including empty string
@@ -45,5 +77,15 @@ func (c Config) GetVariablePrefix(defaultPrefix string) string {
 		// backward compatibility: if caller set VariablePrefix but didn't mark as set
 		return c.VariablePrefix
 	}
+	if c.ResourceName != "" {
+		return composeName(c.ResourceName, defaultPrefix)
+	}
 	return defaultPrefix
 }
+
+func (c Config) GetResourceName() string {
+	if c.ResourceName == "" {
+		return "this"
+	}
+	return c.ResourceName
+}
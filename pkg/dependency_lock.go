@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// dependencyLockFile is the `.terraform.lock.hcl` written by `terraform init`.
+type dependencyLockFile struct {
+	Providers []lockedProvider `hcl:"provider,block"`
+	Remain    hcl.Body         `hcl:",remain"`
+}
+
+type lockedProvider struct {
+	Source      string   `hcl:"source,label"`
+	Version     string   `hcl:"version"`
+	Constraints *string  `hcl:"constraints,optional"`
+	Hashes      []string `hcl:"hashes,optional"`
+}
+
+// loadLockedProvider returns addr's entry in the dependency lock file at path, or nil when the file or the entry
+// doesn't exist.
+func loadLockedProvider(path string, addr providerAddress) (*lockedProvider, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	f, diag := hclparse.NewParser().ParseHCLFile(path)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("failed to parse dependency lock file %s: %s", path, diag.Error())
+	}
+	var lock dependencyLockFile
+	if diag = gohcl.DecodeBody(f.Body, nil, &lock); diag.HasErrors() {
+		return nil, fmt.Errorf("failed to decode dependency lock file %s: %s", path, diag.Error())
+	}
+	for _, p := range lock.Providers {
+		if strings.EqualFold(p.Source, addr.String()) {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLockedProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".terraform.lock.hcl")
+	require.NoError(t, os.WriteFile(path, []byte(`
+# This file is maintained automatically by "terraform init".
+# Manual edits may be lost in future updates.
+
+provider "registry.terraform.io/hashicorp/azurerm" {
+  version     = "4.39.0"
+  constraints = ">= 4.0.0"
+  hashes = [
+    "h1:abc=",
+    "zh:0123",
+  ]
+}
+
+provider "example.com/acme/internalcloud" {
+  version = "1.0.0"
+}
+`), 0600))
+	azurerm := providerAddress{hostname: defaultRegistryHost, namespace: "hashicorp", providerType: "azurerm"}
+	locked, err := loadLockedProvider(path, azurerm)
+	require.NoError(t, err)
+	require.NotNil(t, locked)
+	assert.Equal(t, "4.39.0", locked.Version)
+	assert.Equal(t, []string{"h1:abc=", "zh:0123"}, locked.Hashes)
+
+	locked, err = loadLockedProvider(path, internalCloud)
+	require.NoError(t, err)
+	require.NotNil(t, locked)
+	assert.Empty(t, locked.Hashes)
+
+	locked, err = loadLockedProvider(path, providerAddress{hostname: defaultRegistryHost, namespace: "hashicorp", providerType: "aws"})
+	require.NoError(t, err)
+	assert.Nil(t, locked)
+
+	locked, err = loadLockedProvider(filepath.Join(t.TempDir(), ".terraform.lock.hcl"), azurerm)
+	require.NoError(t, err)
+	assert.Nil(t, locked)
+}
@@ -1,44 +1,51 @@
 package pkg
 
 import (
-	"bufio"
 	"fmt"
-	"github.com/ahmetb/go-linq/v3"
-	"regexp"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 )
 
+// urlTemplates locate documents on GitHub by git ref, the tag of a provider version, and resource type without vendor.
 var urlTemplates = map[string]string{
-	"azurerm": "https://raw.githubusercontent.com/hashicorp/terraform-provider-azurerm/main/website/docs/r/%s.html.markdown",
-	"azuread": "https://raw.githubusercontent.com/hashicorp/terraform-provider-azuread/main/docs/resources/%s.md",
-	"aws":     "https://raw.githubusercontent.com/hashicorp/terraform-provider-aws/main/website/docs/r/%s.html.markdown",
-	"google":  "https://raw.githubusercontent.com/hashicorp/terraform-provider-google/main/website/docs/r/%s.html.markdown",
+	"azurerm": "https://raw.githubusercontent.com/hashicorp/terraform-provider-azurerm/%s/website/docs/r/%s.html.markdown",
+	"azuread": "https://raw.githubusercontent.com/hashicorp/terraform-provider-azuread/%s/docs/resources/%s.md",
+	"aws":     "https://raw.githubusercontent.com/hashicorp/terraform-provider-aws/%s/website/docs/r/%s.html.markdown",
+	"google":  "https://raw.githubusercontent.com/hashicorp/terraform-provider-google/%s/website/docs/r/%s.html.markdown",
 }
 
-var backQuoteNameRegexp = regexp.MustCompile(`\x60.+\x60`)
-var argumentsHeadlineRegex = regexp.MustCompile("## [A|a]rguments? [R|r]eference")
-var timeoutsHeadlineRegex = regexp.MustCompile("## [T|t]imeouts?")
-var attributesHeadlineRegex = regexp.MustCompile("## [A|a]ttributes? [R|r]eference")
-var importHeadlineRegex = regexp.MustCompile("## [I|i]mports?")
-var awsNestedBlockDescriptionKeyWords = linq.From([]string{
-	"following",
-	"argument",
-	"configuration block:",
-})
-var awsTimeoutsUrl = "https://developer.hashicorp.com/terraform/language/resources/syntax#operation-timeouts"
-var azureTimeoutsUrl = "https://www.terraform.io/docs/configuration/resources.html#timeouts"
-var googleTimeoutsUrl = "https://developer.hashicorp.com/terraform/plugin/sdkv2/resources/retries-and-customizable-timeouts"
-var timeoutsUrlSuffix = "resources/syntax#operation-timeouts"
+// localDocumentPaths are the places of a resource's document in a provider repository, relative to Config.DocsDir,
+// by resource type without vendor and full resource type.
+var localDocumentPaths = []string{
+	"website/docs/r/%s.html.markdown",
+	"website/docs/r/%s.markdown",
+	"website/docs/r/%s.md",
+	"docs/resources/%s.md",
+	"r/%s.html.markdown",
+	"r/%s.markdown",
+	"resources/%s.md",
+	"%s.html.markdown",
+	"%s.markdown",
+	"%s.md",
+}
+
+// documentContents caches documents by resource type and provider, so a run fetches each of them once.
+var documentContents sync.Map
 
 type Document struct {
 	resourceType string
 	getContent   func(string) (string, error)
 }
 
-func newDocument(resourceType string) Document {
+func newDocument(resourceType string, cfg Config) Document {
 	return Document{
 		resourceType: resourceType,
-		getContent:   content,
+		getContent: func(resourceType string) (string, error) {
+			return content(resourceType, cfg)
+		},
 	}
 }
 
@@ -46,168 +53,87 @@ func (d Document) content() (string, error) {
 	return d.getContent(d.resourceType)
 }
 
-var content = func(resourceType string) (string, error) {
+// content returns the documentation of resourceType for the provider version its schema comes from: from
+// Config.DocsDir if set, otherwise from the SchemaSource if it's a DocumentSource.
+var content = func(resourceType string, cfg Config) (string, error) {
 	if !resourceTypeValid(resourceType) {
 		return "", fmt.Errorf("unsupported resource type: %s", resourceType)
 	}
-	vendor := resourceVendor(resourceType)
-	tplt, ok := urlTemplates[vendor]
+	if cfg.DocsDir != "" {
+		return localDocument(cfg.DocsDir, resourceType)
+	}
+	documents, ok := cfg.GetSchemaSource().(DocumentSource)
 	if !ok {
 		return "", nil
 	}
-	return fetchURLContent(fmt.Sprintf(tplt, resourceTypeWithoutVendor(resourceType)))
+	return documents.ResourceDocument(resourceType, cfg)
 }
 
-func (d Document) parseDocument() (map[string]argumentDescription, error) {
-	r := make(map[string]argumentDescription, 0)
-	markdown, err := d.content()
+// registryContent returns the documentation of resourceType from the Terraform Registry or GitHub, once per run.
+func registryContent(resourceType string, cfg Config) (string, error) {
+	key := strings.Join([]string{resourceType, cfg.ProviderSource, cfg.ProviderNamespace, cfg.ProviderVersion}, "|")
+	if markdown, ok := documentContents.Load(key); ok {
+		return markdown.(string), nil
+	}
+	markdown, err := fetchDocument(resourceType, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("error on get document: %s", err.Error())
+		return "", err
 	}
-	markdown = strings.Replace(markdown, "\r\n", "\n", -1)
-	// aws document's lines need extra line break
-	markdown = strings.Replace(markdown, "\n*", "\n\n*", -1)
-	scanner := bufio.NewScanner(strings.NewReader(markdown))
-
-	parsing := false
-	lineBuilder := strings.Builder{}
-	blockName := ""
-	// Iterate through the lines in the input string
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if d.beginParse(line) {
-			parsing = true
-			continue
-		} else if d.endParse(line) {
-			parsing = false
-			continue
-		}
-
-		if parsing {
-			if line != "" {
-				if lineBuilder.Len() > 0 {
-					lineBuilder.WriteString(" ")
-				}
-				lineBuilder.WriteString(line)
-			} else {
-				line = lineBuilder.String()
-				lineBuilder.Reset()
-				if timeoutsBlock := d.nestedBlockHead(line); timeoutsBlock != "" {
-					blockName = timeoutsBlock
-					continue
-				}
+	documentContents.Store(key, markdown)
+	return markdown, nil
+}
 
-				arg := d.parseArgument(line)
-				if arg == nil {
-					continue
-				}
-				argName := arg.name
-				if blockName != "" {
-					argName = fmt.Sprintf("%s.%s", blockName, argName)
-				}
-				_, ok := r[argName]
-				if !ok {
-					r[argName] = *arg
-				}
+// localDocument reads the document of resourceType from docsDir, a resource without document has an empty one.
+func localDocument(docsDir, resourceType string) (string, error) {
+	for _, name := range []string{resourceTypeWithoutVendor(resourceType), resourceType} {
+		for _, p := range localDocumentPaths {
+			markdown, err := os.ReadFile(filepath.Join(docsDir, filepath.FromSlash(fmt.Sprintf(p, name))))
+			if err == nil {
+				return string(markdown), nil
+			}
+			if !os.IsNotExist(err) {
+				return "", fmt.Errorf("failed to read document of %s: %w", resourceType, err)
 			}
 		}
 	}
-
-	// Check if there was an error scanning the input string
-	if err = scanner.Err(); err != nil {
-		fmt.Println("Error scanning the string:", err)
-	}
-	return r, nil
-}
-
-func (d Document) beginParse(line string) bool {
-	if argumentsHeadlineRegex.MatchString(line) {
-		return true
-	}
-	if timeoutsHeadlineRegex.MatchString(line) {
-		return true
-	}
-	return false
-}
-
-func (d Document) endParse(line string) bool {
-	return attributesHeadlineRegex.MatchString(line) || importHeadlineRegex.MatchString(line)
+	return "", nil
 }
 
-func (d Document) parseArgument(line string) *argumentDescription {
-	line = d.clean(line)
-	if !strings.HasPrefix(line, "*") &&
-		!strings.Contains(line, "-") {
-		return nil
-	}
-	split := strings.Split(line, " - ")
-	if len(split) < 2 {
-		return nil
-	}
-	name := split[0]
-	name = strings.TrimPrefix(name, "* `")
-	name = strings.TrimSuffix(name, "`")
-	description := split[1]
-	return &argumentDescription{
-		name:         name,
-		desc:         description,
-		defaultValue: nil,
+// fetchDocument reads the document of the exact provider version the schema comes from, from the Terraform Registry,
+// and falls back to the GitHub repositories of the providers in urlTemplates at the tag of that version. Other
+// providers the registry doesn't document have no document, which is logged as a warning.
+func fetchDocument(resourceType string, cfg Config) (string, error) {
+	tplt, hasFallback := urlTemplates[resourceVendor(resourceType)]
+	addr, version, err := documentedProvider(resourceType, cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the provider version of %s: %w", resourceType, err)
 	}
-}
-
-func (d Document) clean(line string) string {
-	if line == "" {
-		return line
+	markdown, err := providerDocument(addr, version, resourceType)
+	if err == nil && markdown != "" {
+		return markdown, nil
 	}
-	line = strings.Replace(line, " – ", " - ", -1)
-	isDelimiterLine := linq.From([]rune(line)).Distinct().All(func(i interface{}) bool {
-		c := i.(rune)
-		return c == ' ' || c == '-'
-	})
-	if isDelimiterLine {
-		return line
+	if !hasFallback {
+		if err == nil {
+			err = fmt.Errorf("empty document")
+		}
+		log.Printf("Warning: no document of %s %s, generating without descriptions, defaults and validations: %s", resourceType, version, err)
+		return "", nil
 	}
-	if strings.HasPrefix(line, "- ") {
-		line = fmt.Sprintf("* %s", line[2:])
+	markdown, err = fetchURLContent(fmt.Sprintf(tplt, "v"+version, resourceTypeWithoutVendor(resourceType)))
+	if err != nil {
+		return "", fmt.Errorf("no document of %s %s found: %w", resourceType, version, err)
 	}
-	return line
+	return markdown, nil
 }
 
-func (d Document) nestedBlockHead(line string) string {
-	predicts := []func() bool{
-		func() bool {
-			return strings.HasSuffix(line, "block supports the following:")
-		},
-		func() bool {
-			return backQuoteNameRegexp.MatchString(line) && awsNestedBlockDescriptionKeyWords.All(func(i interface{}) bool {
-				return strings.Contains(line, i.(string))
-			})
-		},
-		func() bool {
-			return backQuoteNameRegexp.MatchString(line) && strings.HasSuffix(line, "block supports:")
-		},
-		func() bool {
-			return d.isTimeoutsDescription(line)
-		},
+func (d Document) parseDocument() (map[string]argumentDescription, error) {
+	markdown, err := d.content()
+	if err != nil {
+		return nil, fmt.Errorf("error on get document: %s", err.Error())
 	}
-	for _, p := range predicts {
-		if p() {
-			nbName := backQuoteNameRegexp.FindString(line)
-			if nbName != "" {
-				return nbName[1 : len(nbName)-1]
-			}
-			if d.isTimeoutsDescription(line) {
-				return "timeouts"
-			}
-			return ""
-		}
+	markdown = strings.Replace(markdown, "\r\n", "\n", -1)
+	if isTfPluginDocs(markdown) {
+		return withDocumentedValues(d.parseTfPluginDocs(markdown)), nil
 	}
-	return ""
-}
-
-func (d Document) isTimeoutsDescription(line string) bool {
-	return strings.Contains(line, awsTimeoutsUrl) ||
-		strings.Contains(line, azureTimeoutsUrl) ||
-		strings.Contains(line, googleTimeoutsUrl) ||
-		strings.Contains(line, timeoutsUrlSuffix)
+	return withDocumentedValues(d.parseMarkdown(markdown)), nil
 }
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files of the document parser")
+
+// TestDocumentParse_Golden parses real provider documents, the `*.markdown` files of this package, and compares the
+// descriptions with `testdata/documents/<resource type>.golden.json`. Run with -update to accept parser changes.
+func TestDocumentParse_Golden(t *testing.T) {
+	documents, err := filepath.Glob("*.markdown")
+	require.NoError(t, err)
+	require.NotEmpty(t, documents)
+	for _, document := range documents {
+		resourceType := strings.TrimSuffix(document, ".markdown")
+		t.Run(resourceType, func(t *testing.T) {
+			d := newDocument(resourceType, Config{})
+			d.getContent = doc(readFileAsString(document))
+			args, err := d.parseDocument()
+			require.NoError(t, err)
+			descriptions := make(map[string]string)
+			for k, arg := range args {
+				descriptions[k] = arg.desc
+			}
+			actual, err := json.MarshalIndent(descriptions, "", "  ")
+			require.NoError(t, err)
+			golden := filepath.Join("testdata", "documents", resourceType+".golden.json")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, append(actual, '\n'), 0600))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// noteRegex matches the `->`, `~>` and `!>` notes of Terraform documents.
+var noteRegex = regexp.MustCompile(`^(->|~>|!>)\s*`)
+
+// headingBlockRegex matches headings naming a nested block, e.g. `### encryption_config` or `### vpc_config Arguments`.
+var headingBlockRegex = regexp.MustCompile("^`?([a-z][a-z0-9_]*)`?(\\s+(Arguments?|Configuration Blocks?|[Bb]locks?))?$")
+
+// blockPathRegex matches the code span naming a nested block in a lead-in, e.g. `protect_config.workload_config`.
+var blockPathRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+
+// blockNounRegex matches the text following the name of a block in a lead-in, e.g. "A `secret` block supports the
+// following:" or "... in the `provider` configuration block:".
+var blockNounRegex = regexp.MustCompile(`^\s+(configuration\s+)?blocks?\b`)
+
+type blockHeading struct {
+	level int
+	path  string
+}
+
+// markdownParser collects argument descriptions from a classic Terraform resource document, e.g.
+// `website/docs/r/<name>.html.markdown`, walking its markdown AST.
+type markdownParser struct {
+	src    []byte
+	result map[string]argumentDescription
+	// parsing is set within the `Arguments Reference` and `Timeouts` sections.
+	parsing bool
+	// block is the path of the nested block the arguments being read belong to, empty for the resource itself. Blocks
+	// the document only names are qualified by qualifyDocumentPaths once the whole document is read.
+	block string
+	// headings are the blocks named by the headings above, e.g. `#### provider` under `### encryption_config`.
+	headings []blockHeading
+	// lastKey is the argument a following note is attached to.
+	lastKey string
+}
+
+func (d Document) parseMarkdown(markdown string) map[string]argumentDescription {
+	p := &markdownParser{
+		src:    []byte(stripFrontMatter(markdown)),
+		result: make(map[string]argumentDescription),
+	}
+	root := goldmark.DefaultParser().Parse(text.NewReader(p.src))
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		p.node(n)
+	}
+	return qualifyDocumentPaths(p.result)
+}
+
+func (p *markdownParser) node(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		p.lastKey = ""
+		p.heading(n.Level, p.text(n))
+	case *ast.Paragraph, *ast.TextBlock:
+		content := p.text(n)
+		if note := noteRegex.ReplaceAllString(content, ""); note != content {
+			p.attachNote(note)
+			return
+		}
+		p.lastKey = ""
+		if p.parsing {
+			// e.g. "A `secret` block supports the following:" before the list of the block's arguments
+			if nb := p.leadIn(n, 0); nb != "" {
+				p.block = nb
+			}
+		}
+	case *ast.List:
+		if p.parsing {
+			p.list(n, p.block)
+		}
+	case *ast.ThematicBreak:
+		// `---` separates the arguments of a document from its nested blocks
+	default:
+		p.lastKey = ""
+	}
+}
+
+func (p *markdownParser) heading(level int, title string) {
+	lower := strings.ToLower(title)
+	switch {
+	case strings.HasPrefix(lower, "argument") && strings.Contains(lower, "reference"):
+		p.parsing, p.block, p.headings = true, "", nil
+	case strings.HasPrefix(lower, "timeout"):
+		p.parsing, p.block, p.headings = true, "timeouts", nil
+	case strings.HasPrefix(lower, "attribute") && strings.Contains(lower, "reference"), strings.HasPrefix(lower, "import"):
+		p.parsing = false
+	case p.parsing:
+		m := headingBlockRegex.FindStringSubmatch(title)
+		if m == nil {
+			return
+		}
+		for len(p.headings) > 0 && p.headings[len(p.headings)-1].level >= level {
+			p.headings = p.headings[:len(p.headings)-1]
+		}
+		p.block = m[1]
+		if len(p.headings) > 0 {
+			p.block = fmt.Sprintf("%s.%s", p.headings[len(p.headings)-1].path, m[1])
+		}
+		p.headings = append(p.headings, blockHeading{level: level, path: p.block})
+	}
+}
+
+// list reads the arguments of a bullet list, block is the nested block they belong to.
+func (p *markdownParser) list(list ast.Node, block string) {
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		p.listItem(item, block)
+	}
+}
+
+func (p *markdownParser) listItem(item ast.Node, block string) {
+	var arg *argumentDescription
+	nestedBlock := ""
+	for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c.(type) {
+		case *ast.Paragraph, *ast.TextBlock:
+			lines := p.lines(c)
+			if len(lines) > 1 || arg != nil {
+				// e.g. "The following arguments are supported in the `x` configuration block:" before a nested list
+				last := c.Lines().At(c.Lines().Len() - 1)
+				if nb := p.leadIn(c, last.Start); nb != "" {
+					nestedBlock = nb
+					lines = lines[:len(lines)-1]
+				}
+			}
+			content := strings.Join(lines, " ")
+			if arg == nil {
+				if arg = parseArgumentText(content); arg == nil {
+					return
+				}
+				continue
+			}
+			arg.desc = joinDescription(arg.desc, noteRegex.ReplaceAllString(content, ""))
+		case *ast.List:
+			if arg == nil {
+				return
+			}
+			if nestedBlock != "" {
+				// the nested list documents the block this argument is, or a block named elsewhere
+				path := nestedBlock
+				if nestedBlock == arg.name && block != "" {
+					path = fmt.Sprintf("%s.%s", block, nestedBlock)
+				}
+				p.list(c, path)
+				continue
+			}
+			// plain bullets, e.g. the values an argument accepts, are part of its description
+			for nested := c.FirstChild(); nested != nil; nested = nested.NextSibling() {
+				arg.desc = joinDescription(arg.desc, p.text(nested))
+			}
+		}
+	}
+	if arg == nil {
+		return
+	}
+	key := arg.name
+	if block != "" {
+		key = fmt.Sprintf("%s.%s", block, arg.name)
+	}
+	if _, ok := p.result[key]; !ok {
+		p.result[key] = *arg
+	}
+	p.lastKey = key
+}
+
+// leadIn returns the nested block a paragraph introduces: a code span followed by "block", from offset from on, in a
+// text ending with a colon that a list of arguments follows, e.g. "A `secret` block supports the following:".
+func (p *markdownParser) leadIn(paragraph ast.Node, from int) string {
+	lines := p.lines(paragraph)
+	if len(lines) == 0 || !strings.HasSuffix(lines[len(lines)-1], ":") || !p.argumentList(paragraph.NextSibling()) {
+		return ""
+	}
+	for c := paragraph.FirstChild(); c != nil; c = c.NextSibling() {
+		span, ok := c.(*ast.CodeSpan)
+		if !ok || span.FirstChild() == nil {
+			continue
+		}
+		segment := span.FirstChild().(*ast.Text).Segment
+		name := string(segment.Value(p.src))
+		if segment.Start < from || !blockPathRegex.MatchString(name) {
+			continue
+		}
+		if next, ok := span.NextSibling().(*ast.Text); ok && blockNounRegex.Match(next.Segment.Value(p.src)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// argumentList tells whether n, or the first node after the notes starting at n, is a list of items starting with the
+// name of an argument, e.g. "`name` - description".
+func (p *markdownParser) argumentList(n ast.Node) bool {
+	for n != nil && n.Kind() == ast.KindParagraph && noteRegex.MatchString(p.text(n)) {
+		n = n.NextSibling()
+	}
+	list, ok := n.(*ast.List)
+	if !ok {
+		return false
+	}
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		if item.FirstChild() != nil && item.FirstChild().FirstChild() != nil && item.FirstChild().FirstChild().Kind() == ast.KindCodeSpan {
+			return true
+		}
+	}
+	return false
+}
+
+// attachNote appends a note to the description of the argument right before it.
+func (p *markdownParser) attachNote(note string) {
+	if !p.parsing || p.lastKey == "" {
+		return
+	}
+	arg := p.result[p.lastKey]
+	arg.desc = joinDescription(arg.desc, note)
+	p.result[p.lastKey] = arg
+}
+
+// lines returns the source lines of a block node, markdown inline syntax like code spans and links included.
+func (p *markdownParser) lines(n ast.Node) []string {
+	var lines []string
+	if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+		for i := 0; i < n.Lines().Len(); i++ {
+			segment := n.Lines().At(i)
+			if line := strings.TrimSpace(string(segment.Value(p.src))); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		lines = append(lines, p.lines(c)...)
+	}
+	return lines
+}
+
+func (p *markdownParser) text(n ast.Node) string {
+	return strings.Join(p.lines(n), " ")
+}
+
+// parseArgumentText parses the first paragraph of an argument's list item, "`name` - description".
+func parseArgumentText(content string) *argumentDescription {
+	content = strings.Replace(content, " – ", " - ", 1)
+	if !strings.HasPrefix(content, "`") {
+		return nil
+	}
+	name, desc, found := strings.Cut(content, " - ")
+	if !found {
+		return nil
+	}
+	name = strings.Trim(strings.TrimSpace(name), "`")
+	if name == "" || strings.ContainsAny(name, "` ") {
+		return nil
+	}
+	return &argumentDescription{
+		name: name,
+		desc: strings.TrimSpace(desc),
+	}
+}
+
+func joinDescription(desc, more string) string {
+	more = strings.TrimSpace(more)
+	if more == "" {
+		return desc
+	}
+	if desc == "" {
+		return more
+	}
+	return fmt.Sprintf("%s %s", desc, more)
+}
+
+// stripFrontMatter removes the YAML front matter of a document, which would be read as a heading otherwise.
+func stripFrontMatter(markdown string) string {
+	if !strings.HasPrefix(markdown, "---\n") {
+		return markdown
+	}
+	if end := strings.Index(markdown[4:], "\n---\n"); end >= 0 {
+		return markdown[4+end+5:]
+	}
+	return markdown
+}
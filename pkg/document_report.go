@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DocumentReport lists the differences between a resource's schema and its documentation. Arguments of nested blocks
+// are named by their full path, e.g. `<block>.<nested_block>.<argument>`.
+type DocumentReport struct {
+	ResourceType string
+	// MissingInDocument are arguments of the schema the document doesn't describe, their variables have no description.
+	MissingInDocument []string
+	// MissingInSchema are documented arguments the schema doesn't have, e.g. documented for another provider version.
+	MissingInSchema []string
+	// AssumedRequired are fields of nested blocks the provider declares as attributes, that neither the schema nor the
+	// document tells are optional, so their variables require them.
+	AssumedRequired []string
+}
+
+// Empty reports whether schema and documentation match.
+func (r *DocumentReport) Empty() bool {
+	return len(r.MissingInDocument) == 0 && len(r.MissingInSchema) == 0 && len(r.AssumedRequired) == 0
+}
+
+func (r *DocumentReport) String() string {
+	var sb strings.Builder
+	if len(r.MissingInDocument) > 0 || len(r.MissingInSchema) > 0 {
+		sb.WriteString(fmt.Sprintf("The documentation of %s doesn't match its schema\n", r.ResourceType))
+	}
+	sections := []struct {
+		title string
+		items []string
+	}{
+		{"Arguments missing in the documentation", r.MissingInDocument},
+		{"Documented arguments missing in the schema", r.MissingInSchema},
+		{fmt.Sprintf("Arguments of %s assumed required, the schema and documentation don't tell if they're optional", r.ResourceType), r.AssumedRequired},
+	}
+	for _, s := range sections {
+		if len(s.items) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s:\n", s.title))
+		for _, item := range s.items {
+			sb.WriteString(fmt.Sprintf("  - %s\n", item))
+		}
+	}
+	return sb.String()
+}
+
+// CheckDocument compares the schema of the generated resource with its documentation. Resources without documentation
+// get an empty report.
+func CheckDocument(cmd ResourceGenerateCommand) (*DocumentReport, error) {
+	report := &DocumentReport{ResourceType: cmd.ResourceType()}
+	r, document, err := documentedResource(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return report, nil
+	}
+	report.AssumedRequired = restoreOptionality(r, document)
+	if len(document) == 0 {
+		return report, nil
+	}
+	arguments := make(map[string]bool)
+	schemaArguments(r, arguments)
+	for _, path := range sortedKeys(arguments) {
+		if _, ok := describe(document, path); !ok {
+			report.MissingInDocument = append(report.MissingInDocument, path)
+		}
+	}
+	for _, key := range sortedKeys(document) {
+		if !documentedInSchema(arguments, key) {
+			report.MissingInSchema = append(report.MissingInSchema, key)
+		}
+	}
+	return report, nil
+}
+
+// documentedResource returns the resource block of cmd with its documentation, a nil block if cmd has no documentation
+// source.
+func documentedResource(cmd ResourceGenerateCommand) (*resourceBlock, map[string]argumentDescription, error) {
+	documented, ok := cmd.(withDocument)
+	if !ok {
+		return nil, nil, nil
+	}
+	document, err := documented.Doc()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error on load and parse document: %w", err)
+	}
+	schema, err := cmd.Schema()
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := newResourceBlock(cmd.ResourceBlockType(), schema, cmd.Config())
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, document, nil
+}
+
+// documentedInSchema reports whether the documented key names an argument of the schema, either by its full path or,
+// for blocks the document doesn't place, by the end of it.
+func documentedInSchema(arguments map[string]bool, key string) bool {
+	if arguments[key] {
+		return true
+	}
+	for path := range arguments {
+		if strings.HasSuffix(path, "."+key) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaArguments collects the paths of the arguments of b that can be documented.
+func schemaArguments(b block, arguments map[string]bool) {
+	for _, a := range b.attributes() {
+		if a.skipAttribute() {
+			continue
+		}
+		arguments[documentPath(b, a.name)] = true
+		if a.AttributeNestedType != nil {
+			for _, path := range nestedAttributePaths(documentPath(b, a.name), a.AttributeNestedType) {
+				arguments[path] = true
+			}
+		}
+	}
+	for _, nb := range b.nestedBlocks() {
+		if nb.blockReadOnly() {
+			continue
+		}
+		// timeouts are described in a section of their own, not as an argument
+		if nb.name != "timeouts" {
+			arguments[documentPath(b, nb.name)] = true
+		}
+		schemaArguments(nb, arguments)
+	}
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDocument(t *testing.T) {
+	previous := content
+	content = func(string, Config) (string, error) {
+		return `## Arguments Reference
+
+* ` + "`name`" + ` - (Required) The name.
+
+* ` + "`old_flag`" + ` - (Optional) Removed in 2.0.0.
+
+* ` + "`network`" + ` - (Optional) A network block.
+
+A ` + "`network`" + ` block supports the following:
+
+* ` + "`subnet_id`" + ` - (Optional) The subnet.
+
+## Attributes Reference
+`, nil
+	}
+	t.Cleanup(func() {
+		content = previous
+	})
+	report, err := CheckDocument(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"identity", "identity.type", "network.dns", "size", "sku"}, report.MissingInDocument)
+	assert.Equal(t, []string{"old_flag"}, report.MissingInSchema)
+	assert.False(t, report.Empty())
+	assert.Contains(t, report.String(), "Documented arguments missing in the schema:\n  - old_flag\n")
+}
+
+func TestCheckDocument_Undocumented(t *testing.T) {
+	previous := content
+	content = func(string, Config) (string, error) {
+		return "", nil
+	}
+	t.Cleanup(func() {
+		content = previous
+	})
+	report, err := CheckDocument(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.True(t, report.Empty())
+}
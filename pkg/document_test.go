@@ -3,6 +3,8 @@ package pkg
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -67,7 +69,7 @@ func TestDocumentParse_RootArgumentDescription(t *testing.T) {
 	for i := 0; i < len(cases); i++ {
 		c := cases[i]
 		t.Run(c.resourceType, func(t *testing.T) {
-			d := newDocument(c.resourceType)
+			d := newDocument(c.resourceType, Config{})
 			d.getContent = doc(c.document)
 			args, err := d.parseDocument()
 			actual := args[c.path]
@@ -94,7 +96,7 @@ func TestDocumentParse_NestedBlockArgumentDescription(t *testing.T) {
 			resourceType: "azurerm_kubernetes_cluster",
 			document:     aksMarkdown,
 			path:         "aci_connector_linux.subnet_name",
-			expected:     `(Required) The subnet name for the virtual nodes to run.`,
+			expected:     `(Required) The subnet name for the virtual nodes to run. **Note:** At this time ACI Connectors are not supported in Azure China. **Note:** AKS will add a delegation to the subnet named here. To prevent further runs from failing you should make sure that the subnet you create for virtual nodes has a delegation, like so.`,
 		},
 		{
 			resourceType: "azurerm_kubernetes_cluster",
@@ -105,7 +107,7 @@ func TestDocumentParse_NestedBlockArgumentDescription(t *testing.T) {
 		{
 			resourceType: "azurerm_kubernetes_cluster",
 			document:     aksMarkdown,
-			path:         "linux_os_config.swap_file_size_mb",
+			path:         "default_node_pool.linux_os_config.swap_file_size_mb",
 			expected:     `(Optional) Specifies the size of the swap file on each node in MB. Changing this forces a new resource to be created.`,
 		},
 		{
@@ -117,7 +119,7 @@ func TestDocumentParse_NestedBlockArgumentDescription(t *testing.T) {
 		{
 			resourceType: "aws_eks_cluster",
 			document:     awsEksMarkdown,
-			path:         "provider.key_arn",
+			path:         "encryption_config.provider.key_arn",
 			expected:     "(Required) ARN of the Key Management Service (KMS) customer master key (CMK). The CMK must be symmetric, created in the same region as the cluster, and if the CMK was created in a different account, the user must have access to the CMK. For more information, see [Allowing Users in Other Accounts to Use a CMK in the AWS Key Management Service Developer Guide](https://docs.aws.amazon.com/kms/latest/developerguide/key-policy-modifying-external-accounts.html).",
 		},
 		{
@@ -130,7 +132,7 @@ func TestDocumentParse_NestedBlockArgumentDescription(t *testing.T) {
 	for i := 0; i < len(cases); i++ {
 		c := cases[i]
 		t.Run(fmt.Sprintf("%s.%s", c.resourceType, c.path), func(t *testing.T) {
-			d := newDocument(c.resourceType)
+			d := newDocument(c.resourceType, Config{})
 			d.getContent = doc(c.document)
 			args, err := d.parseDocument()
 			actual := args[c.path]
@@ -165,7 +167,7 @@ func TestDocumentParse_Timeouts(t *testing.T) {
 	for i := 0; i < len(cases); i++ {
 		c := cases[i]
 		t.Run(c.resourceType, func(t *testing.T) {
-			d := newDocument(c.resourceType)
+			d := newDocument(c.resourceType, Config{})
 			d.getContent = doc(c.document)
 			args, err := d.parseDocument()
 			actual := args["timeouts.create"]
@@ -175,8 +177,93 @@ func TestDocumentParse_Timeouts(t *testing.T) {
 	}
 }
 
+func TestDocumentParse_NestedBlockLeadIn(t *testing.T) {
+	markdown := `## Arguments Reference
+
+* ` + "`maintenance_window`" + ` - (Optional) A ` + "`maintenance_window`" + ` block as defined below.
+
+---
+
+A ` + "`maintenance_window`" + ` block supports the following:
+
+~> **Note:** This block only applies to dedicated clusters.
+
+* ` + "`mode`" + ` - (Optional) The mode of the window.
+
+When ` + "`mode`" + ` is set to ` + "`Custom`" + ` the following properties can be specified:
+
+* ` + "`start`" + ` - (Optional) The start of the window.
+
+* ` + "`allowed`" + ` - (Optional) An ` + "`allowed`" + ` block as defined below.
+
+  The following arguments are supported in the ` + "`allowed`" + ` configuration block:
+
+  * ` + "`day`" + ` - (Required) A day in a week.
+
+## Attributes Reference
+`
+	d := newDocument("azurerm_kubernetes_cluster", Config{})
+	d.getContent = doc(markdown)
+	args, err := d.parseDocument()
+	require.NoError(t, err)
+	assert.Equal(t, "(Optional) The mode of the window.", args["maintenance_window.mode"].desc)
+	assert.Equal(t, "(Optional) The start of the window.", args["maintenance_window.start"].desc)
+	assert.Equal(t, "(Optional) An `allowed` block as defined below.", args["maintenance_window.allowed"].desc)
+	assert.Equal(t, "(Required) A day in a week.", args["maintenance_window.allowed.day"].desc)
+	assert.NotContains(t, args, "mode.start")
+}
+
 func doc(d string) func(string) (string, error) {
 	return func(string) (string, error) {
 		return d, nil
 	}
 }
+
+func TestDocumentParse_LocalDocsDir(t *testing.T) {
+	cases := map[string]string{
+		"classic":      "website/docs/r/kubernetes_cluster.html.markdown",
+		"tfplugindocs": "docs/resources/kubernetes_cluster.md",
+		"docs folder":  "kubernetes_cluster.md",
+	}
+	for name, path := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(aksMarkdown), 0600))
+			doc, err := newDocument("azurerm_kubernetes_cluster", Config{DocsDir: dir}).parseDocument()
+			require.NoError(t, err)
+			assert.Equal(t, "(Required) The name of the Managed Kubernetes Cluster to create. Changing this forces a new resource to be created.", doc["name"].desc)
+		})
+	}
+}
+
+func TestDocumentParse_LocalDocsDirWithoutDocument(t *testing.T) {
+	doc, err := newDocument("azurerm_kubernetes_cluster", Config{DocsDir: t.TempDir()}).parseDocument()
+	require.NoError(t, err)
+	assert.Empty(t, doc)
+}
+
+func TestDocumentParse_TfPluginDocs(t *testing.T) {
+	d := newDocument("azuread_application_password", Config{})
+	d.getContent = doc(readFileAsString("azuread_application_password.markdown"))
+	doc, err := d.parseDocument()
+	require.NoError(t, err)
+	expected := map[string]string{
+		"application_id":               "(Required) The resource ID of the application for which this password should be created. Changing this field forces a new resource to be created.",
+		"display_name":                 "(Optional) A display name for the password. Changing this field forces a new resource to be created.",
+		"rotate_when_changed":          "(Optional) Arbitrary map of values that, when changed, will trigger rotation of the password.",
+		"rotation":                     "(Optional) Rotation settings.",
+		"timeouts":                     "(Optional)",
+		"rotation.days":                "(Required) Number of days between rotations.",
+		"rotation.schedule":            "(Optional)",
+		"rotation.schedule.start_time": "(Optional) The time of the first rotation.",
+		"timeouts.create":              "(Optional)",
+		"timeouts.delete":              "(Optional)",
+	}
+	actual := make(map[string]string)
+	for k, v := range doc {
+		actual[k] = v.desc
+		assert.True(t, strings.HasSuffix(k, v.name))
+	}
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tfplugindocs generates the documents of many providers, e.g. azuread v3 and most plugin framework providers, with a
+// `## Schema` section listing arguments under `### Required` and `### Optional`, and each nested block in a
+// `### Nested Schema for` section of its own.
+var tfPluginDocsSchemaHeadlineRegex = regexp.MustCompile(`(?m)^## Schema\s*$`)
+var tfPluginDocsNestedSchemaRegex = regexp.MustCompile("^#+ Nested Schema for `([^`]+)`")
+var tfPluginDocsSectionRegex = regexp.MustCompile(`^(?:#+ )?(Required|Optional|Read-Only):?$`)
+var tfPluginDocsArgumentRegex = regexp.MustCompile("^- `([^`]+)`(?: \\(([^)]*)\\))?\\s*(.*)$")
+var tfPluginDocsNestedLinkRegex = regexp.MustCompile(`\s*\(see \[below for nested schema\]\([^)]*\)\)`)
+
+func isTfPluginDocs(markdown string) bool {
+	return tfPluginDocsSchemaHeadlineRegex.MatchString(markdown)
+}
+
+// parseTfPluginDocs parses a tfplugindocs document. Nested Schema sections name the full path of their block, so
+// arguments are keyed by their full path, read-only attributes are left out.
+func (d Document) parseTfPluginDocs(markdown string) map[string]argumentDescription {
+	r := make(map[string]argumentDescription)
+	inSchema := false
+	block := ""
+	section := ""
+	key := ""
+	var current *argumentDescription
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.desc = strings.TrimSpace(current.desc)
+		if _, ok := r[key]; !ok {
+			r[key] = *current
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSpace(line)
+		if tfPluginDocsSchemaHeadlineRegex.MatchString(line) {
+			inSchema = true
+			continue
+		}
+		if !inSchema || strings.HasPrefix(line, "<a id=") {
+			continue
+		}
+		if m := tfPluginDocsNestedSchemaRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			block, section = m[1], ""
+			continue
+		}
+		if m := tfPluginDocsSectionRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			section = m[1]
+			continue
+		}
+		if strings.HasPrefix(line, "## ") {
+			// the schema section ends at the next top level headline, e.g. `## Import`
+			flush()
+			inSchema = false
+			continue
+		}
+		if m := tfPluginDocsArgumentRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			if section != "Required" && section != "Optional" {
+				continue
+			}
+			key = m[1]
+			if block != "" {
+				key = fmt.Sprintf("%s.%s", block, key)
+			}
+			current = &argumentDescription{
+				name: m[1],
+				desc: fmt.Sprintf("(%s) %s", section, tfPluginDocsNestedLinkRegex.ReplaceAllString(m[3], "")),
+			}
+			continue
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		if current != nil {
+			current.desc = fmt.Sprintf("%s %s", current.desc, line)
+		}
+	}
+	flush()
+	return qualifyDocumentPaths(r)
+}
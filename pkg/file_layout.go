@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// FileLayout decides which file of a module each generated block is written to.
+type FileLayout string
+
+const (
+	// DefaultLayout writes variables to `variables.tf` and everything else to `main.tf`.
+	DefaultLayout FileLayout = "default"
+	// PerResourceLayout writes one pair of files per resource, e.g. `main.frontend.tf` and `variables.frontend.tf`.
+	PerResourceLayout FileLayout = "per-resource"
+	// AvmLayout follows the Azure Verified Modules file layout: `terraform.tf`, `variables.tf`, `locals.tf`,
+	// `outputs.tf`, and `main.tf` for everything else.
+	AvmLayout FileLayout = "avm"
+	// SingleFileLayout writes everything to `main.tf`.
+	SingleFileLayout FileLayout = "single"
+)
+
+// FileLayouts lists the supported layouts.
+var FileLayouts = []FileLayout{DefaultLayout, PerResourceLayout, AvmLayout, SingleFileLayout}
+
+// layoutFiles maps block types to file base names per layout, blocks of any other type go to `main`, so block kinds
+// the layout doesn't know about are never dropped.
+var layoutFiles = map[FileLayout]map[string]string{
+	DefaultLayout: {
+		"variable": "variables",
+	},
+	PerResourceLayout: {
+		"variable": "variables",
+	},
+	AvmLayout: {
+		"terraform": "terraform",
+		"variable":  "variables",
+		"locals":    "locals",
+		"output":    "outputs",
+	},
+	SingleFileLayout: {},
+}
+
+// ParseFileLayout parses a layout name, an empty name is the DefaultLayout.
+func ParseFileLayout(name string) (FileLayout, error) {
+	if name == "" {
+		return DefaultLayout, nil
+	}
+	for _, l := range FileLayouts {
+		if string(l) == name {
+			return l, nil
+		}
+	}
+	var names []string
+	for _, l := range FileLayouts {
+		names = append(names, string(l))
+	}
+	return "", fmt.Errorf("unknown file layout %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// fileName returns the name of the file block goes to, label is the label of the generated resource.
+func (l FileLayout) fileName(block *hclwrite.Block, label string) string {
+	files, ok := layoutFiles[l]
+	if !ok {
+		files = layoutFiles[DefaultLayout]
+	}
+	name, ok := files[block.Type()]
+	if !ok {
+		name = "main"
+	}
+	if l == PerResourceLayout && label != "" {
+		name = fmt.Sprintf("%s.%s", name, label)
+	}
+	return name + ".tf"
+}
+
+// generatedResourceLabel returns the label of the first resource, or data source, in generated blocks.
+func generatedResourceLabel(blocks []*hclwrite.Block) string {
+	for _, b := range blocks {
+		if (b.Type() == "resource" || b.Type() == "data") && len(b.Labels()) == 2 {
+			return b.Labels()[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generatedWithAllBlockKinds = `variable "subnet_name" {
+  type = string
+}
+
+resource "fake_subnet" "frontend" {
+  name = var.subnet_name
+}
+
+data "fake_network" "frontend" {
+  name = "vnet"
+}
+
+locals {
+  subnet_id = fake_subnet.frontend.id
+}
+
+output "subnet_id" {
+  value = local.subnet_id
+}
+
+import {
+  to = fake_subnet.frontend
+  id = "subnet-id"
+}
+
+moved {
+  from = fake_subnet.this
+  to   = fake_subnet.frontend
+}
+
+terraform {
+  required_version = ">= 1.5"
+}
+`
+
+func generatedFileNames(t *testing.T, layout FileLayout) []string {
+	files, err := SplitGeneratedCode(generatedWithAllBlockKinds, layout)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestFileLayouts(t *testing.T) {
+	cases := map[FileLayout][]string{
+		DefaultLayout:     {"variables.tf", "main.tf"},
+		PerResourceLayout: {"variables.frontend.tf", "main.frontend.tf"},
+		AvmLayout:         {"variables.tf", "main.tf", "locals.tf", "outputs.tf", "terraform.tf"},
+		SingleFileLayout:  {"main.tf"},
+	}
+	for layout, expected := range cases {
+		t.Run(string(layout), func(t *testing.T) {
+			assert.Equal(t, expected, generatedFileNames(t, layout))
+		})
+	}
+}
+
+func TestFileLayout_NoBlockIsDropped(t *testing.T) {
+	for _, layout := range FileLayouts {
+		files, err := SplitGeneratedCode(generatedWithAllBlockKinds, layout)
+		require.NoError(t, err)
+		var content string
+		for _, f := range files {
+			content += string(f.Content)
+		}
+		for _, header := range []string{`variable "subnet_name"`, `resource "fake_subnet" "frontend"`, `data "fake_network" "frontend"`, "locals {", `output "subnet_id"`, "import {", "moved {", "terraform {"} {
+			assert.Contains(t, content, header, string(layout))
+		}
+	}
+}
+
+func TestParseFileLayout(t *testing.T) {
+	layout, err := ParseFileLayout("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLayout, layout)
+	layout, err = ParseFileLayout("avm")
+	require.NoError(t, err)
+	assert.Equal(t, AvmLayout, layout)
+	_, err = ParseFileLayout("flat")
+	assert.ErrorContains(t, err, `unknown file layout "flat"`)
+}
+
+func TestMergeIntoModule_PerResourceLayoutRunTwice(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, MergeIntoModule(dir, generatedWithAllBlockKinds, PerResourceLayout, false))
+	main := readModuleFile(t, dir, "main.frontend.tf")
+	require.NoError(t, MergeIntoModule(dir, generatedWithAllBlockKinds, PerResourceLayout, false))
+	assert.Equal(t, main, readModuleFile(t, dir, "main.frontend.tf"))
+	assert.Contains(t, readModuleFile(t, dir, "variables.frontend.tf"), `variable "subnet_name"`)
+	_, err := os.Stat(filepath.Join(dir, "main.tf"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"fmt"
+)
+
+// ForceNewReport lists the arguments of a resource whose change replaces the resource, ForceNew arguments as their
+// documentation marks them.
+type ForceNewReport struct {
+	ResourceType string   `json:"resource_type"`
+	Arguments    []string `json:"force_new_arguments"`
+}
+
+// ForceNewArguments returns the ForceNew arguments of the generated resource, named by their full path. Resources
+// without documentation get an empty report.
+func ForceNewArguments(cmd ResourceGenerateCommand) (*ForceNewReport, error) {
+	report := &ForceNewReport{ResourceType: cmd.ResourceType(), Arguments: []string{}}
+	r, document, err := documentedResource(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return report, nil
+	}
+	report.Arguments = append(report.Arguments, forceNewArguments(r, document)...)
+	return report, nil
+}
+
+// forceNewArguments returns the paths of the ForceNew arguments of b and its nested blocks.
+func forceNewArguments(b block, descriptions map[string]argumentDescription) []string {
+	arguments := make(map[string]bool)
+	schemaArguments(b, arguments)
+	var forceNew []string
+	for _, path := range sortedKeys(arguments) {
+		if d, ok := describe(descriptions, path); ok && d.forceNew {
+			forceNew = append(forceNew, path)
+		}
+	}
+	return forceNew
+}
+
+// forceNewWarningTokens returns the section of a variable description warning that changing the ForceNew arguments of
+// b replaces the resource, no tokens if b has none.
+func forceNewWarningTokens(b block, descriptions map[string]argumentDescription) *tokens {
+	t := newTokens()
+	arguments := forceNewArguments(b, descriptions)
+	if len(arguments) == 0 {
+		return t
+	}
+	t.newLine().
+		ident("---", 2).
+		newLine().
+		ident("Changing the following arguments replaces the resource, Terraform plans to destroy it and create a new one (`-/+`):", 2).
+		newLine()
+	for _, a := range arguments {
+		t.ident(fmt.Sprintf("- `%s`", a), 2).newLine()
+	}
+	return t
+}
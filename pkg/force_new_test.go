@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const forceNewTestDocument = `## Arguments Reference
+
+* ` + "`name`" + ` - (Required) The name. Changing this forces a new resource to be created.
+
+* ` + "`sku`" + ` - (Optional) The SKU.
+
+* ` + "`probe`" + ` - (Optional) One or more probe blocks.
+
+A ` + "`probe`" + ` block supports the following:
+
+* ` + "`transport`" + ` - (Required) Type of probe. Changing this value will force a new cluster to be created.
+
+* ` + "`port`" + ` - (Optional) The port.
+
+## Attributes Reference
+`
+
+func stubForceNewDocument(t *testing.T) {
+	previous := content
+	content = func(string, Config) (string, error) {
+		return forceNewTestDocument, nil
+	}
+	t.Cleanup(func() {
+		content = previous
+	})
+}
+
+func TestWithDocumentedValues_ForceNew(t *testing.T) {
+	cases := map[string]bool{
+		"(Required) The name. Changing this forces a new resource to be created.":             true,
+		"(Required) The ID. Changing this field forces a new resource to be created.":         true,
+		"(Optional) The version. Changing this value will force a new cluster to be created.": true,
+		"(Optional) The tags. Changing this updates the resource in place.":                   false,
+		"(Optional) Whether a new resource is created. Defaults to `false`.":                  false,
+	}
+	for desc, expected := range cases {
+		d := withDocumentedValues(map[string]argumentDescription{"arg": {name: "arg", desc: desc}})["arg"]
+		assert.Equal(t, expected, d.forceNew, desc)
+	}
+}
+
+func TestForceNewArguments(t *testing.T) {
+	stubForceNewDocument(t)
+	report, err := ForceNewArguments(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    enumTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, &ForceNewReport{
+		ResourceType: "fake_resource",
+		Arguments:    []string{"name", "probe.transport"},
+	}, report)
+}
+
+func TestGenerateResource_ForceNewWarningInObjectVariableDescription(t *testing.T) {
+	stubForceNewDocument(t)
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		Mode:            UniVariable,
+		SchemaSource:    enumTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.Regexp(t, "replaces the resource, Terraform plans to destroy it and create a new one \\(`-/\\+`\\):\\s+- `name`\\s+- `probe.transport`\\s+EOT", generated)
+	assert.NotContains(t, generated, "lifecycle")
+}
+
+func TestGenerateResource_PreventDestroy(t *testing.T) {
+	stubForceNewDocument(t)
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    enumTestSchemas,
+		ProviderVersion: "1.0.0",
+		PreventDestroy:  true,
+	}, nil))
+	require.NoError(t, err)
+	assert.Regexp(t, `lifecycle {\s+prevent_destroy\s+= true\s+}`, generated)
+}
@@ -23,6 +23,7 @@ func GenerateResource(generateCmd ResourceGenerateCommand) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("error on load and parse document: %s", err.Error())
 	}
+	restoreOptionality(r, document)
 	var generated string
 	if cfg.GetMode() == UniVariable {
 		generated, err = r.generateUniVarResource(document)
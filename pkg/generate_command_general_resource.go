@@ -19,7 +19,7 @@ func (g generalResource) ResourceType() string {
 }
 
 func (g generalResource) Doc() (map[string]argumentDescription, error) {
-	return newDocument(g.resourceType).parseDocument()
+	return newDocument(g.resourceType, g.cfg).parseDocument()
 }
 
 func (g generalResource) ResourceBlockType() string {
@@ -31,7 +31,7 @@ func (g generalResource) Config() Config {
 }
 
 func (g generalResource) Schema() (*tfjson.Schema, error) {
-	schema, err := getResourceSchema(g.resourceType, g.cfg.ProviderNamespace, g.cfg.ProviderVersion)
+	schema, err := g.cfg.GetSchemaSource().ResourceSchema(g.resourceType, g.cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get schema for %s: %w", g.resourceType, err)
 	}
@@ -9,7 +9,11 @@ import (
 
 func TestDoc(t *testing.T) {
 	sut := generalResource{
-		resourceType: "azurerm_resource_group",
+		resourceType: "azurerm_kubernetes_cluster",
+		cfg: Config{
+			SchemaSource: testSchemaSource,
+			DocsDir:      ".",
+		},
 	}
 	docs, err := sut.Doc()
 	require.NoError(t, err)
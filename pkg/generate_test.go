@@ -25,7 +25,8 @@ func TestGenerateResource_SimpleUniVarResource(t *testing.T) {
 	resourceType := "azurerm_resource_group"
 	schema := azurermschema.Resources[resourceType]
 	generated, err := GenerateResource(NewResourceGenerateCommand(resourceType, Config{
-		Mode: UniVariable,
+		SchemaSource: testSchemaSource,
+		Mode:         UniVariable,
 	}, nil))
 	require.NoError(t, err)
 	config, diag := hclsyntax.ParseConfig([]byte(generated), "", hcl.InitialPos)
@@ -55,6 +56,7 @@ func TestGenerateResource_CustomVariablePrefix_MultipleVariables(t *testing.T) {
 	resourceType := "azurerm_resource_group"
 	schema := azurermschema.Resources[resourceType]
 	generated, err := GenerateResource(NewResourceGenerateCommand(resourceType, Config{
+		SchemaSource:      testSchemaSource,
 		Mode:              MultipleVariables,
 		VariablePrefix:    "rg",
 		VariablePrefixSet: true,
@@ -80,6 +82,7 @@ func TestGenerateResource_CustomVariablePrefix_UniVar(t *testing.T) {
 	resourceType := "azurerm_resource_group"
 	schema := azurermschema.Resources[resourceType]
 	generated, err := GenerateResource(NewResourceGenerateCommand(resourceType, Config{
+		SchemaSource:      testSchemaSource,
 		Mode:              UniVariable,
 		VariablePrefix:    "proj",
 		VariablePrefixSet: true,
@@ -103,6 +106,7 @@ func TestGenerateResource_EmptyVariablePrefix_MultipleVariables(t *testing.T) {
 	resourceType := "azurerm_resource_group"
 	schema := azurermschema.Resources[resourceType]
 	generated, err := GenerateResource(NewResourceGenerateCommand(resourceType, Config{
+		SchemaSource:      testSchemaSource,
 		Mode:              MultipleVariables,
 		VariablePrefix:    "",
 		VariablePrefixSet: true,
@@ -125,6 +129,7 @@ func TestGenerateResource_EmptyVariablePrefix_MultipleVariables(t *testing.T) {
 func TestGenerateResource_EmptyVariablePrefix_UniVarFallsBack(t *testing.T) {
 	resourceType := "azurerm_resource_group"
 	generated, err := GenerateResource(NewResourceGenerateCommand(resourceType, Config{
+		SchemaSource:      testSchemaSource,
 		Mode:              UniVariable,
 		VariablePrefix:    "",
 		VariablePrefixSet: true,
@@ -136,7 +141,8 @@ func TestGenerateResource_EmptyVariablePrefix_UniVarFallsBack(t *testing.T) {
 
 func TestGenerateResource_ObjectInAttributeShouldGenerateNestedBlock(t *testing.T) {
 	code, err := GenerateResource(NewResourceGenerateCommand("azurerm_container_group", Config{
-		Mode: MultipleVariables,
+		SchemaSource: testSchemaSource,
+		Mode:         MultipleVariables,
 	}, nil))
 	require.NoError(t, err)
 	assert.Contains(t, code, `dynamic "exposed_port" {`)
@@ -166,7 +172,7 @@ func TestGenerateVariableTypeForWholeResource(t *testing.T) {
 	schema := azurermschema_v2.Resources["azurerm_site_recovery_replicated_vm"]
 	r, err := newResourceBlock("azurerm_site_recovery_replicated_vm", schema, Config{})
 	require.NoError(t, err)
-	variableType := generateVariableType(r, true)
+	variableType := generateVariableType(r, true, nil)
 	//`managed_disk` and `network_interface` are `SchemaConfigModeAttr` so schema info was lost, we cannot know whether their attributes are optional or not. https://github.com/hashicorp/terraform-provider-azurerm/blob/v2.99.0/internal/services/recoveryservices/site_recovery_replicated_vm_resource.go#L118-L120
 	expected := `object({
   name = string
@@ -224,7 +230,8 @@ func TestGenerateResource_SkippedAttributeShouldNotAppearInVariableDescription(t
 		t.Run(fmt.Sprintf("%s.%s", c.resourceType, c.caseName), func(t *testing.T) {
 			resourceType := c.resourceType
 			generated, err := GenerateResource(NewResourceGenerateCommand(resourceType, Config{
-				Mode: UniVariable,
+				SchemaSource: testSchemaSource,
+				Mode:         UniVariable,
 			}, nil))
 			require.NoError(t, err)
 			assert.NotContains(t, generated, fmt.Sprintf("- `%s` -", c.caseName))
@@ -239,7 +246,7 @@ func TestGenerateVariableTypeForSetOfObject(t *testing.T) {
 			MinItems: 0,
 			MaxItems: 2,
 		},
-	}, true)
+	}, true, nil)
 	expected := `set(object({
 }))`
 	assert.Equal(t, strings.ReplaceAll(expected, " ", ""), strings.ReplaceAll(variableType, " ", ""))
@@ -253,7 +260,7 @@ func TestGenerateVariableTypeForListOfObject(t *testing.T) {
 			MinItems:    0,
 			MaxItems:    2,
 		},
-	}, true)
+	}, true, nil)
 	expected := `list(object({
 }))`
 	assert.Equal(t, strings.ReplaceAll(expected, " ", ""), strings.ReplaceAll(variableType, " ", ""))
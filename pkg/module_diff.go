@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// moduleFilePatterns match the Terraform files of a module, in both syntaxes.
+var moduleFilePatterns = []string{"*.tf", "*.tf.json"}
+
+// DiffModules returns a unified diff of the Terraform files of two module directories, e.g. a module and a copy of it
+// newres wrote into during a dry run. Files are compared by name, missing files count as empty.
+func DiffModules(originalDir, changedDir string) (string, error) {
+	names := make(map[string]bool)
+	for _, dir := range []string{originalDir, changedDir} {
+		paths, err := modulePaths(dir)
+		if err != nil {
+			return "", err
+		}
+		for _, p := range paths {
+			names[filepath.Base(p)] = true
+		}
+	}
+	var sb strings.Builder
+	for _, name := range sortedKeys(names) {
+		original, err := readOptionalFile(filepath.Join(originalDir, name))
+		if err != nil {
+			return "", err
+		}
+		changed, err := readOptionalFile(filepath.Join(changedDir, name))
+		if err != nil {
+			return "", err
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(original),
+			B:        difflib.SplitLines(changed),
+			FromFile: "a/" + name,
+			ToFile:   "b/" + name,
+			Context:  3,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to diff %s: %w", name, err)
+		}
+		sb.WriteString(diff)
+	}
+	return sb.String(), nil
+}
+
+func readOptionalFile(path string) (string, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(content), nil
+}
+
+// CopyModuleFiles copies the Terraform files of srcDir into destDir, a missing srcDir is an empty module.
+func CopyModuleFiles(srcDir, destDir string) error {
+	paths, err := modulePaths(srcDir)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		content, err := os.ReadFile(filepath.Clean(p))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if err = os.WriteFile(filepath.Join(destDir, filepath.Base(p)), content, 0600); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func modulePaths(dir string) ([]string, error) {
+	var paths []string
+	for _, pattern := range moduleFilePatterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
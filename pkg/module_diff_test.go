@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffModules_PreviewMergeWithoutTouchingModule(t *testing.T) {
+	dir := t.TempDir()
+	handWritten := `locals {
+  hand_written = true
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(handWritten), 0600))
+	preview := t.TempDir()
+	require.NoError(t, CopyModuleFiles(dir, preview))
+	require.NoError(t, MergeIntoModule(preview, generatedRg, DefaultLayout, false))
+
+	diff, err := DiffModules(dir, preview)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- a/main.tf\n+++ b/main.tf\n")
+	assert.Contains(t, diff, "+resource \"azurerm_resource_group\" \"this\" {\n")
+	assert.Contains(t, diff, "--- a/variables.tf\n+++ b/variables.tf\n")
+	assert.Contains(t, diff, "+variable \"resource_group_name\" {\n")
+	assert.Equal(t, handWritten, readModuleFile(t, dir, "main.tf"))
+	_, err = os.Stat(filepath.Join(dir, "variables.tf"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, CopyModuleFiles(preview, dir))
+	diff, err = DiffModules(dir, preview)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestSplitGeneratedCode(t *testing.T) {
+	files, err := SplitGeneratedCode(generatedRg, DefaultLayout)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "variables.tf", files[0].Name)
+	assert.Contains(t, string(files[0].Content), `variable "resource_group_tags"`)
+	assert.NotContains(t, string(files[0].Content), "resource \"")
+	assert.Equal(t, "main.tf", files[1].Name)
+	assert.Contains(t, string(files[1].Content), `resource "azurerm_resource_group" "this"`)
+}
@@ -0,0 +1,234 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+const variablesFileName = "variables.tf"
+
+// GeneratedFile is generated code meant for one file of a module.
+type GeneratedFile struct {
+	Name    string
+	Content []byte
+}
+
+// SplitGeneratedCode splits generated code into the files layout puts it in, in the order they first appear.
+func SplitGeneratedCode(generated string, layout FileLayout) ([]GeneratedFile, error) {
+	generatedFile, diag := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	var names []string
+	files := make(map[string]*hclwrite.File)
+	blocks := generatedFile.Body().Blocks()
+	label := generatedResourceLabel(blocks)
+	for _, b := range blocks {
+		name := layout.fileName(b, label)
+		f, ok := files[name]
+		if !ok {
+			f = hclwrite.NewEmptyFile()
+			files[name] = f
+			names = append(names, name)
+		} else {
+			f.Body().AppendNewline()
+		}
+		f.Body().AppendBlock(b)
+	}
+	var result []GeneratedFile
+	for _, name := range names {
+		result = append(result, GeneratedFile{
+			Name:    name,
+			Content: files[name].Bytes(),
+		})
+	}
+	return result, nil
+}
+
+// tfFile is a Terraform configuration file of a module, parsed for editing.
+type tfFile struct {
+	path    string
+	file    *hclwrite.File
+	changed bool
+}
+
+func readTfFiles(dir string) ([]*tfFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var files []*tfFile
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		f, diag := hclwrite.ParseConfig(content, path, hcl.InitialPos)
+		if diag.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %s", path, diag.Error())
+		}
+		files = append(files, &tfFile{
+			path: path,
+			file: f,
+		})
+	}
+	return files, nil
+}
+
+// tfJsonFile is a Terraform JSON configuration file of a module, e.g. `main.tf.json`, parsed for editing.
+type tfJsonFile struct {
+	path    string
+	root    *jsonObject
+	changed bool
+}
+
+func readTfJsonFiles(dir string) ([]*tfJsonFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var files []*tfJsonFile
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		root, err := parseJsonObject(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		files = append(files, &tfJsonFile{
+			path: path,
+			root: root,
+		})
+	}
+	return files, nil
+}
+
+// jsonModuleFile returns the JSON file called name in dir, a new empty one if it doesn't exist yet.
+func jsonModuleFile(dir string, files []*tfJsonFile, name string) *tfJsonFile {
+	path := filepath.Join(dir, name)
+	for _, f := range files {
+		if f.path == path {
+			return f
+		}
+	}
+	return &tfJsonFile{
+		path: path,
+		root: newJsonObject(),
+	}
+}
+
+// findJsonBlock returns the object holding the block with blockType and labels, keyed by its last label, and its file.
+func findJsonBlock(files []*tfJsonFile, blockType string, labels ...string) (*jsonObject, *tfJsonFile) {
+	for _, f := range files {
+		parent, ok := f.root.values[blockType].(*jsonObject)
+		for _, l := range labels[:len(labels)-1] {
+			if !ok {
+				break
+			}
+			parent, ok = parent.values[l].(*jsonObject)
+		}
+		if !ok {
+			continue
+		}
+		if _, exists := parent.values[labels[len(labels)-1]]; exists {
+			return parent, f
+		}
+	}
+	return nil, nil
+}
+
+func hasIdenticalJsonBlock(files []*tfJsonFile, b jsonBlock) bool {
+	for _, f := range files {
+		for _, existing := range jsonBlocks(f.root) {
+			if existing.blockType == b.blockType && sameJson(existing.body, b.body) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeChangedJsonFiles writes every changed JSON file once, files may be listed more than once.
+func writeChangedJsonFiles(files []*tfJsonFile) error {
+	written := make(map[string]bool)
+	for _, f := range files {
+		if !f.changed || written[f.path] {
+			continue
+		}
+		content, err := json.MarshalIndent(f.root, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(f.path, append(content, '\n'), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.path, err)
+		}
+		written[f.path] = true
+	}
+	return nil
+}
+
+// moduleFile returns the file called name in dir, a new empty one if it doesn't exist yet.
+func moduleFile(dir string, files []*tfFile, name string) *tfFile {
+	path := filepath.Join(dir, name)
+	for _, f := range files {
+		if f.path == path {
+			return f
+		}
+	}
+	return &tfFile{
+		path: path,
+		file: hclwrite.NewEmptyFile(),
+	}
+}
+
+func findBlock(files []*tfFile, blockType string, labels ...string) (*hclwrite.Block, *tfFile) {
+	for _, f := range files {
+		for _, b := range f.file.Body().Blocks() {
+			if b.Type() == blockType && slices.Equal(b.Labels(), labels) {
+				return b, f
+			}
+		}
+	}
+	return nil, nil
+}
+
+// writeChangedFiles writes every changed file once, files may be listed more than once.
+func writeChangedFiles(files []*tfFile) error {
+	written := make(map[string]bool)
+	for _, f := range files {
+		if !f.changed || written[f.path] {
+			continue
+		}
+		if err := os.WriteFile(f.path, f.file.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.path, err)
+		}
+		written[f.path] = true
+	}
+	return nil
+}
+
+// blockSignature describes a block's content regardless of formatting and the order of its arguments and nested
+// blocks, so blocks reformatted by autofix still compare equal.
+func blockSignature(b *hclwrite.Block) string {
+	var items []string
+	for name, attr := range b.Body().Attributes() {
+		items = append(items, fmt.Sprintf("%s=%s", name, strings.Join(strings.Fields(string(attr.Expr().BuildTokens(nil).Bytes())), " ")))
+	}
+	for _, nb := range b.Body().Blocks() {
+		items = append(items, blockSignature(nb))
+	}
+	sort.Strings(items)
+	return fmt.Sprintf("%s %q {%s}", b.Type(), b.Labels(), strings.Join(items, ";"))
+}
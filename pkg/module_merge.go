@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// mergedVariableAttributes are the variable arguments re-generation refreshes, others may have been changed by hand.
+var mergedVariableAttributes = []string{"type", "description"}
+
+// ConflictError lists existing blocks that differ from the generated ones and would be overwritten.
+type ConflictError struct {
+	Conflicts []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("found existing blocks that differ from the generated ones, use --force to overwrite them:\n  - %s", strings.Join(e.Conflicts, "\n  - "))
+}
+
+// MergeIntoModule writes generated code into the module in dir. New blocks go to the file layout puts them in, e.g.
+// `variables.tf` and `main.tf`; existing variables, in any file, get their type, description and generated validations
+// refreshed. An existing
+// block that differs from the generated one is a conflict, reported as *ConflictError without changing any file unless
+// force is set, in which case it's replaced.
+func MergeIntoModule(dir, generated string, layout FileLayout, force bool) error {
+	generatedFile, diag := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	files, err := readTfFiles(dir)
+	if err != nil {
+		return err
+	}
+	targets := make(map[string]*tfFile)
+	var conflicts []string
+	blocks := generatedFile.Body().Blocks()
+	label := generatedResourceLabel(blocks)
+	for _, b := range blocks {
+		name := layout.fileName(b, label)
+		target, ok := targets[name]
+		if !ok {
+			target = moduleFile(dir, files, name)
+			targets[name] = target
+		}
+		existing, existingFile := findBlock(files, b.Type(), b.Labels()...)
+		if len(b.Labels()) == 0 {
+			// blocks like locals may appear many times, only an identical one is the same block
+			if hasIdenticalBlock(files, b) {
+				continue
+			}
+			existing = nil
+		}
+		if existing == nil {
+			if len(target.file.Body().Blocks()) > 0 {
+				target.file.Body().AppendNewline()
+			}
+			target.file.Body().AppendBlock(b)
+			target.changed = true
+			continue
+		}
+		if b.Type() == "variable" {
+			existingFile.changed = mergeVariable(existing, b) || existingFile.changed
+			continue
+		}
+		if blockSignature(existing) == blockSignature(b) {
+			continue
+		}
+		if !force {
+			conflicts = append(conflicts, fmt.Sprintf("%s in %s", blockHeader(b), filepath.Base(existingFile.path)))
+			continue
+		}
+		existingFile.file.Body().RemoveBlock(existing)
+		existingFile.file.Body().AppendBlock(b)
+		existingFile.changed = true
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	for _, name := range sortedKeys(targets) {
+		files = append(files, targets[name])
+	}
+	return writeChangedFiles(files)
+}
+
+// mergeVariable refreshes existing with the generated type, description and validations, and reports whether it
+// changed.
+func mergeVariable(existing, generated *hclwrite.Block) bool {
+	before := blockSignature(existing)
+	for _, name := range mergedVariableAttributes {
+		if attr := generated.Body().GetAttribute(name); attr != nil {
+			existing.Body().SetAttributeRaw(name, attr.Expr().BuildTokens(nil))
+		}
+	}
+	mergeValidations(existing.Body(), generated.Body())
+	return blockSignature(existing) != before
+}
+
+// mergeValidations replaces the validation blocks newres generated in existing, e.g. for the possible values of an
+// older provider version, with the generated ones. Validations written by hand are kept.
+func mergeValidations(existing, generated *hclwrite.Body) {
+	var stale, validations []*hclwrite.Block
+	var previous, current []string
+	for _, b := range existing.Blocks() {
+		if b.Type() == "validation" && generatedValidation(b) {
+			stale = append(stale, b)
+			previous = append(previous, blockSignature(b))
+		}
+	}
+	for _, b := range generated.Blocks() {
+		if b.Type() == "validation" {
+			validations = append(validations, b)
+			current = append(current, blockSignature(b))
+		}
+	}
+	if slices.Equal(previous, current) {
+		return
+	}
+	for _, b := range stale {
+		existing.RemoveBlock(b)
+	}
+	for _, b := range validations {
+		existing.AppendBlock(b)
+	}
+}
+
+// blockHeader renders a block's type and labels the way they're written, e.g. `resource "azurerm_resource_group" "this"`.
+func blockHeader(b *hclwrite.Block) string {
+	header := []string{b.Type()}
+	for _, l := range b.Labels() {
+		header = append(header, fmt.Sprintf("%q", l))
+	}
+	return strings.Join(header, " ")
+}
+
+func hasIdenticalBlock(files []*tfFile, b *hclwrite.Block) bool {
+	signature := blockSignature(b)
+	for _, f := range files {
+		for _, existing := range f.file.Body().Blocks() {
+			if blockSignature(existing) == signature {
+				return true
+			}
+		}
+	}
+	return false
+}
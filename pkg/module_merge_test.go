@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generatedRg = `variable "resource_group_name" {
+  type        = string
+  description = "The name."
+  nullable    = false
+}
+
+variable "resource_group_tags" {
+  type    = map(string)
+  default = null
+}
+
+resource "azurerm_resource_group" "this" {
+  name = var.resource_group_name
+  tags = var.resource_group_tags
+}
+`
+
+func readModuleFile(t *testing.T, dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestMergeIntoModule_RunTwiceIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, MergeIntoModule(dir, generatedRg, DefaultLayout, false))
+	variables, main := readModuleFile(t, dir, "variables.tf"), readModuleFile(t, dir, "main.tf")
+	require.NoError(t, MergeIntoModule(dir, generatedRg, DefaultLayout, false))
+	assert.Equal(t, variables, readModuleFile(t, dir, "variables.tf"))
+	assert.Equal(t, main, readModuleFile(t, dir, "main.tf"))
+	assert.Equal(t, 1, strings.Count(main, `resource "azurerm_resource_group" "this"`))
+	assert.Equal(t, 1, strings.Count(variables, `variable "resource_group_name"`))
+}
+
+func TestMergeIntoModule_ReorderedResourceIsNotAConflict(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "azurerm_resource_group" "this" {
+  tags  = var.resource_group_tags
+  name  = var.resource_group_name
+}
+`), 0600))
+	assert.NoError(t, MergeIntoModule(dir, generatedRg, DefaultLayout, false))
+}
+
+func TestMergeIntoModule_UpdateVariableAndKeepHandWrittenArguments(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inputs.tf"), []byte(`variable "resource_group_name" {
+  type        = any
+  description = "Old description."
+  nullable    = false
+  validation {
+    condition     = length(var.resource_group_name) > 0
+    error_message = "Must not be empty."
+  }
+}
+`), 0600))
+	require.NoError(t, MergeIntoModule(dir, generatedRg, DefaultLayout, false))
+	inputs := readModuleFile(t, dir, "inputs.tf")
+	assert.Contains(t, inputs, "type        = string")
+	assert.Contains(t, inputs, `"The name."`)
+	assert.Contains(t, inputs, "validation {")
+	variables := readModuleFile(t, dir, "variables.tf")
+	assert.NotContains(t, variables, `variable "resource_group_name"`)
+	assert.Contains(t, variables, `variable "resource_group_tags"`)
+}
+
+const generatedSku = `variable "resource_group_sku" {
+  type    = string
+  default = null
+  validation {
+    condition     = var.resource_group_sku == null ? true : contains(["Basic", "Standard"], var.resource_group_sku)
+    error_message = "The value of ` + "`sku`" + ` must be one of \"Basic\", \"Standard\"."
+  }
+}
+
+resource "azurerm_resource_group" "this" {
+  sku = var.resource_group_sku
+}
+`
+
+func TestMergeIntoModule_RefreshGeneratedValidations(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inputs.tf"), []byte(`variable "resource_group_sku" {
+  type    = string
+  default = null
+  validation {
+    condition     = var.resource_group_sku == null ? true : contains(["Basic"], var.resource_group_sku)
+    error_message = "The value of `+"`sku`"+` must be one of \"Basic\"."
+  }
+  validation {
+    condition     = var.resource_group_sku != "Legacy"
+    error_message = "Legacy is not allowed here."
+  }
+}
+`), 0600))
+	require.NoError(t, MergeIntoModule(dir, generatedSku, DefaultLayout, false))
+	inputs := readModuleFile(t, dir, "inputs.tf")
+	assert.Contains(t, inputs, `contains(["Basic", "Standard"], var.resource_group_sku)`)
+	assert.NotContains(t, inputs, `contains(["Basic"], var.resource_group_sku)`)
+	assert.Equal(t, 1, strings.Count(inputs, "The value of"))
+	assert.Contains(t, inputs, "Legacy is not allowed here.")
+
+	require.NoError(t, MergeIntoModule(dir, generatedSku, DefaultLayout, false))
+	assert.Equal(t, inputs, readModuleFile(t, dir, "inputs.tf"))
+}
+
+func TestMergeIntoModule_ConflictingResource(t *testing.T) {
+	dir := t.TempDir()
+	handWritten := `resource "azurerm_resource_group" "this" {
+  name     = "fixed"
+  location = "eastus"
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(handWritten), 0600))
+	err := MergeIntoModule(dir, generatedRg, DefaultLayout, false)
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{`resource "azurerm_resource_group" "this" in main.tf`}, conflict.Conflicts)
+	assert.Equal(t, handWritten, readModuleFile(t, dir, "main.tf"))
+	_, err = os.Stat(filepath.Join(dir, "variables.tf"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, MergeIntoModule(dir, generatedRg, DefaultLayout, true))
+	main := readModuleFile(t, dir, "main.tf")
+	assert.NotContains(t, main, `"fixed"`)
+	assert.Contains(t, main, "var.resource_group_name")
+}
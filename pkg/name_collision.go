@@ -0,0 +1,205 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// maxPrefixAttempts bounds how many numbered variable prefixes are tried to avoid collisions.
+const maxPrefixAttempts = 100
+
+// GenerateForModule generates the resource like GenerateResource, making sure its variables don't collide with
+// variables in dir, in HCL or Terraform JSON files, that belong to something else. A resource block with the same
+// type and label already in dir that is wired with generated variables is the same resource being generated again,
+// so the variables it's wired with aren't collisions; any other one is reported as *ResourceLabelError.
+// Colliding variables get a numbered default prefix, e.g. `resource_group_2_name`; with an explicit
+// Config.VariablePrefix the collisions are reported as an error instead.
+func GenerateForModule(dir, resourceType string, cfg Config, parameters map[string]string) (string, error) {
+	files, err := readTfFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	jsonFiles, err := readTfJsonFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	cmd := NewResourceGenerateCommand(resourceType, cfg, parameters)
+	generated, err := GenerateResource(cmd)
+	if err != nil {
+		return "", err
+	}
+	if err = checkResourceLabel(files, jsonFiles, generated); err != nil {
+		return "", err
+	}
+	collisions, err := variableCollisions(files, jsonFiles, generated)
+	if err != nil || len(collisions) == 0 {
+		return generated, err
+	}
+	if cfg.VariablePrefixSet || cfg.VariablePrefix != "" {
+		return "", fmt.Errorf("variables %s already exist in %s for something else, please choose another --variable-prefix", strings.Join(collisions, ", "), dir)
+	}
+	prefix := cfg.GetVariablePrefix(resourceTypeWithoutVendor(cmd.ResourceBlockType()))
+	for n := 2; n <= maxPrefixAttempts; n++ {
+		renamed, err := renameVariables(generated, prefix, fmt.Sprintf("%s_%d", prefix, n))
+		if err != nil {
+			return "", err
+		}
+		collisions, err := variableCollisions(files, jsonFiles, renamed)
+		if err != nil || len(collisions) == 0 {
+			return renamed, err
+		}
+	}
+	return "", fmt.Errorf("no free variable prefix found for %s in %s", resourceType, dir)
+}
+
+// renameVariables returns generated with its variables named prefix, or starting with `prefix_`, renamed to start
+// with newPrefix instead, along with their `var.` references.
+func renameVariables(generated, prefix, newPrefix string) (string, error) {
+	f, diag := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return "", fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	rename := func(name string) string {
+		if name == prefix || strings.HasPrefix(name, prefix+"_") {
+			return newPrefix + strings.TrimPrefix(name, prefix)
+		}
+		return name
+	}
+	for _, b := range f.Body().Blocks() {
+		if b.Type() == "variable" && len(b.Labels()) == 1 {
+			b.SetLabels([]string{rename(b.Labels()[0])})
+		}
+	}
+	tokens := f.BuildTokens(nil)
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == "var" &&
+			tokens[i+1].Type == hclsyntax.TokenDot && tokens[i+2].Type == hclsyntax.TokenIdent {
+			tokens[i+2].Bytes = []byte(rename(string(tokens[i+2].Bytes)))
+		}
+	}
+	return string(f.Bytes()), nil
+}
+
+// ResourceLabelError is returned when a module already has a resource block with the label of the generated one,
+// which isn't the same resource generated before. Labels are all labels of ResourceType in the module.
+type ResourceLabelError struct {
+	ResourceType string
+	Label        string
+	Labels       []string
+}
+
+func (e *ResourceLabelError) Error() string {
+	return fmt.Sprintf("`resource %q %q` already exists and isn't wired with the generated variables, labels of %s in use: %s; please choose another --name, or pass the --variable-prefix it was generated with",
+		e.ResourceType, e.Label, e.ResourceType, strings.Join(e.Labels, ", "))
+}
+
+// checkResourceLabel returns *ResourceLabelError when files or jsonFiles have a block with the address of the
+// generated resource which references none of the generated variables.
+func checkResourceLabel(files []*tfFile, jsonFiles []*tfJsonFile, generated string) error {
+	generatedFile, diag := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	for _, b := range generatedFile.Body().Blocks() {
+		if b.Type() != "resource" {
+			continue
+		}
+		wired := referencedVariables(b.BuildTokens(nil))
+		var existing []string
+		found := false
+		if block, _ := findBlock(files, b.Type(), b.Labels()...); block != nil {
+			existing, found = referencedVariables(block.BuildTokens(nil)), true
+		}
+		if parent, _ := findJsonBlock(jsonFiles, b.Type(), b.Labels()...); parent != nil {
+			existing, found = append(existing, jsonReferencedVariables(parent.values[b.Labels()[1]])...), true
+		}
+		if !found || len(wired) == 0 || slices.ContainsFunc(existing, func(v string) bool {
+			return slices.Contains(wired, v)
+		}) {
+			continue
+		}
+		return &ResourceLabelError{
+			ResourceType: b.Labels()[0],
+			Label:        b.Labels()[1],
+			Labels:       resourceLabels(files, jsonFiles, b.Labels()[0]),
+		}
+	}
+	return nil
+}
+
+// resourceLabels returns the labels of the resource blocks of resourceType in files and jsonFiles, sorted.
+func resourceLabels(files []*tfFile, jsonFiles []*tfJsonFile, resourceType string) []string {
+	labels := make(map[string]bool)
+	for _, f := range files {
+		for _, b := range f.file.Body().Blocks() {
+			if b.Type() == "resource" && len(b.Labels()) == 2 && b.Labels()[0] == resourceType {
+				labels[b.Labels()[1]] = true
+			}
+		}
+	}
+	for _, f := range jsonFiles {
+		for _, b := range jsonBlocks(f.root) {
+			if b.blockType == "resource" && b.labels[0] == resourceType {
+				labels[b.labels[1]] = true
+			}
+		}
+	}
+	return sortedKeys(labels)
+}
+
+// variableCollisions returns the generated variables which exist in files or jsonFiles but aren't referenced by the
+// existing block of the generated resource.
+func variableCollisions(files []*tfFile, jsonFiles []*tfJsonFile, generated string) ([]string, error) {
+	generatedFile, diag := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	owned := make(map[string]bool)
+	for _, b := range generatedFile.Body().Blocks() {
+		if b.Type() != "resource" {
+			continue
+		}
+		if existing, _ := findBlock(files, b.Type(), b.Labels()...); existing != nil {
+			for _, v := range referencedVariables(existing.BuildTokens(nil)) {
+				owned[v] = true
+			}
+		}
+		if parent, _ := findJsonBlock(jsonFiles, b.Type(), b.Labels()...); parent != nil {
+			for _, v := range jsonReferencedVariables(parent.values[b.Labels()[1]]) {
+				owned[v] = true
+			}
+		}
+	}
+	var collisions []string
+	for _, b := range generatedFile.Body().Blocks() {
+		if b.Type() != "variable" {
+			continue
+		}
+		name := b.Labels()[0]
+		existing, _ := findBlock(files, "variable", name)
+		existingJson, _ := findJsonBlock(jsonFiles, "variable", name)
+		if (existing != nil || existingJson != nil) && !owned[name] {
+			collisions = append(collisions, name)
+		}
+	}
+	sort.Strings(collisions)
+	return collisions, nil
+}
+
+// referencedVariables returns the names of the `var.NAME` references in tokens.
+func referencedVariables(tokens hclwrite.Tokens) []string {
+	var names []string
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == "var" &&
+			tokens[i+1].Type == hclsyntax.TokenDot && tokens[i+2].Type == hclsyntax.TokenIdent {
+			names = append(names, string(tokens[i+2].Bytes))
+		}
+	}
+	return names
+}
@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var subnetSchemas = NewMapSchemaSource(map[string]*tfjson.Schema{
+	"fake_subnet": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name": {AttributeType: cty.String, Required: true},
+			},
+		},
+	},
+})
+
+func TestGenerateForModule_ResourceName(t *testing.T) {
+	generated, err := GenerateForModule(t.TempDir(), "fake_subnet", Config{
+		SchemaSource: subnetSchemas,
+		ResourceName: "frontend",
+	}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, generated, `resource "fake_subnet" "frontend"`)
+	assert.Contains(t, generated, `variable "frontend_subnet_name"`)
+	assert.Contains(t, generated, "name = var.frontend_subnet_name")
+}
+
+func TestGenerateForModule_AvoidCollisionWithVariablesOfOtherResources(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "fake_subnet" "this" {
+  name = var.subnet_name
+}
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "subnet_name" {
+  type = string
+}
+
+variable "frontend_subnet_name" {
+  type = string
+}
+`), 0600))
+
+	generated, err := GenerateForModule(dir, "fake_subnet", Config{SchemaSource: subnetSchemas}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, generated, `variable "subnet_name"`, "generating the same resource again must reuse its variables")
+
+	generated, err = GenerateForModule(dir, "fake_subnet", Config{SchemaSource: subnetSchemas, ResourceName: "frontend"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, generated, `resource "fake_subnet" "frontend"`)
+	assert.Contains(t, generated, `variable "frontend_subnet_2_name"`)
+
+	_, err = GenerateForModule(dir, "fake_subnet", Config{
+		SchemaSource:      subnetSchemas,
+		ResourceName:      "backend",
+		VariablePrefix:    "subnet",
+		VariablePrefixSet: true,
+	}, nil)
+	assert.ErrorContains(t, err, "subnet_name")
+}
+
+func TestGenerateForModule_AvoidCollisionWithJsonVariables(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf.json"), []byte(`{
+  "resource": {
+    "fake_subnet": {
+      "this": {
+        "name": "${var.subnet_name}"
+      }
+    }
+  },
+  "variable": {
+    "subnet_name": {
+      "type": "string"
+    },
+    "frontend_subnet_name": {
+      "type": "string"
+    }
+  }
+}
+`), 0600))
+
+	generated, err := GenerateForModule(dir, "fake_subnet", Config{SchemaSource: subnetSchemas}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, generated, `variable "subnet_name"`, "generating the same resource again must reuse its variables")
+
+	generated, err = GenerateForModule(dir, "fake_subnet", Config{SchemaSource: subnetSchemas, ResourceName: "frontend"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, generated, `variable "frontend_subnet_2_name"`)
+}
+
+func TestGenerateForModule_ReportExistingLabel(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "fake_subnet" "this" {
+  name = "hand-written"
+}
+
+resource "fake_subnet" "backend" {
+  name = var.backend_name
+}
+`), 0600))
+
+	_, err := GenerateForModule(dir, "fake_subnet", Config{SchemaSource: subnetSchemas}, nil)
+	var labelErr *ResourceLabelError
+	require.ErrorAs(t, err, &labelErr)
+	assert.Equal(t, "this", labelErr.Label)
+	assert.Equal(t, []string{"backend", "this"}, labelErr.Labels)
+
+	generated, err := GenerateForModule(dir, "fake_subnet", Config{SchemaSource: subnetSchemas, ResourceName: "frontend"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, generated, `resource "fake_subnet" "frontend"`)
+}
+
+func TestRenameVariables(t *testing.T) {
+	renamed, err := renameVariables(`resource "fake_subnet" "this" {
+  name    = var.subnet_name
+  tags    = var.subnet.tags
+  comment = var.subnetwork
+}
+
+variable "subnet_name" {
+  type = string
+
+  validation {
+    condition     = var.subnet_name != ""
+    error_message = "subnet_name must not be empty."
+  }
+}
+
+variable "subnet" {
+  type = object({})
+}
+`, "subnet", "subnet_2")
+	require.NoError(t, err)
+	assert.Equal(t, `resource "fake_subnet" "this" {
+  name    = var.subnet_2_name
+  tags    = var.subnet_2.tags
+  comment = var.subnetwork
+}
+
+variable "subnet_2_name" {
+  type = string
+
+  validation {
+    condition     = var.subnet_2_name != ""
+    error_message = "subnet_name must not be empty."
+  }
+}
+
+variable "subnet_2" {
+  type = object({})
+}
+`, renamed)
+}
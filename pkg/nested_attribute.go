@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"fmt"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// nestedAttributeType returns the type of an attribute with nested attributes, protocol v6's AttributeNestedType: an
+// object, or a collection of objects, whose optional attributes are optional. Computed only attributes are left out.
+func nestedAttributeType(n *tfjson.SchemaNestedAttributeType) cty.Type {
+	attributes := make(map[string]cty.Type)
+	var optional []string
+	for name, a := range n.Attributes {
+		if a.Computed && !a.Optional && !a.Required {
+			continue
+		}
+		attributes[name] = schemaAttributeType(a)
+		if a.Optional {
+			optional = append(optional, name)
+		}
+	}
+	object := cty.ObjectWithOptionalAttrs(attributes, optional)
+	switch n.NestingMode {
+	case tfjson.SchemaNestingModeList:
+		return cty.List(object)
+	case tfjson.SchemaNestingModeSet:
+		return cty.Set(object)
+	case tfjson.SchemaNestingModeMap:
+		return cty.Map(object)
+	}
+	return object
+}
+
+// schemaAttributeType returns the type of a, built from its nested attributes if it has them.
+func schemaAttributeType(a *tfjson.SchemaAttribute) cty.Type {
+	if a.AttributeNestedType != nil {
+		return nestedAttributeType(a.AttributeNestedType)
+	}
+	return a.AttributeType
+}
+
+// nestedAttributePaths returns the paths of the nested attributes of the attribute at path, theirs included.
+func nestedAttributePaths(path string, n *tfjson.SchemaNestedAttributeType) []string {
+	var paths []string
+	for _, name := range sortedKeys(n.Attributes) {
+		a := n.Attributes[name]
+		if a.Computed && !a.Optional && !a.Required {
+			continue
+		}
+		p := fmt.Sprintf("%s.%s", path, name)
+		paths = append(paths, p)
+		if a.AttributeNestedType != nil {
+			paths = append(paths, nestedAttributePaths(p, a.AttributeNestedType)...)
+		}
+	}
+	return paths
+}
+
+// nestedAttributeDescription lists the nested attributes of the attribute at path like the arguments of a block
+// variable, with their documentation, or their schema's description when they're undocumented.
+func nestedAttributeDescription(path string, n *tfjson.SchemaNestedAttributeType, descriptions map[string]argumentDescription) *tokens {
+	t := newTokens()
+	var nested []string
+	for _, name := range sortedKeys(n.Attributes) {
+		a := n.Attributes[name]
+		if a.Computed && !a.Optional && !a.Required {
+			continue
+		}
+		desc := a.Description
+		if d, ok := describe(descriptions, fmt.Sprintf("%s.%s", path, name)); ok {
+			desc = d.desc
+		}
+		t.ident(fmt.Sprintf("- `%s` - %s", name, desc), 2).newLine()
+		if a.AttributeNestedType != nil {
+			nested = append(nested, name)
+		}
+	}
+	for _, name := range nested {
+		t.newLine().
+			ident("---", 2).
+			newLine().
+			ident(fmt.Sprintf("`%s` supports the following:", name), 2).
+			newLine().
+			rawTokens(nestedAttributeDescription(fmt.Sprintf("%s.%s", path, name), n.Attributes[name].AttributeNestedType, descriptions).Tokens)
+	}
+	return t
+}
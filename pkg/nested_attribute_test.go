@@ -0,0 +1,153 @@
+package pkg
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var nestedAttributeTestSchemas = versionedSchemaSource{
+	"1.0.0": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name": {AttributeType: cty.String, Required: true},
+				"network": {
+					Optional:    true,
+					Description: "The network of the resource.",
+					AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+						NestingMode: tfjson.SchemaNestingModeSingle,
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"subnet_id": {AttributeType: cty.String, Required: true, Description: "The subnet."},
+							"public":    {AttributeType: cty.Bool, Optional: true},
+							"id":        {AttributeType: cty.String, Computed: true},
+						},
+					},
+				},
+				"rule": {
+					Optional: true,
+					AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+						NestingMode: tfjson.SchemaNestingModeList,
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"priority": {AttributeType: cty.Number, Required: true},
+							"ports": {
+								Optional: true,
+								AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+									NestingMode: tfjson.SchemaNestingModeSet,
+									Attributes: map[string]*tfjson.SchemaAttribute{
+										"from": {AttributeType: cty.Number, Required: true},
+										"to":   {AttributeType: cty.Number, Optional: true},
+									},
+								},
+							},
+						},
+					},
+				},
+				"labels": {
+					Optional: true,
+					AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+						NestingMode: tfjson.SchemaNestingModeMap,
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"value": {AttributeType: cty.String, Required: true},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+const nestedAttributeTestDocument = `## Arguments Reference
+
+* ` + "`name`" + ` - (Required) The name.
+
+* ` + "`rule`" + ` - (Optional) One or more rules.
+
+A ` + "`rule`" + ` block supports the following:
+
+* ` + "`priority`" + ` - (Required) The priority of the rule.
+
+## Attributes Reference
+`
+
+func TestNestedAttributeType(t *testing.T) {
+	attributes := nestedAttributeTestSchemas["1.0.0"].Block.Attributes
+	assert.Equal(t, cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+		"subnet_id": cty.String,
+		"public":    cty.Bool,
+	}, []string{"public"}), nestedAttributeType(attributes["network"].AttributeNestedType))
+	assert.Equal(t, cty.List(cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+		"priority": cty.Number,
+		"ports": cty.Set(cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+			"from": cty.Number,
+			"to":   cty.Number,
+		}, []string{"to"})),
+	}, []string{"ports"})), nestedAttributeType(attributes["rule"].AttributeNestedType))
+	assert.Equal(t, cty.Map(cty.Object(map[string]cty.Type{
+		"value": cty.String,
+	})), nestedAttributeType(attributes["labels"].AttributeNestedType))
+}
+
+func TestGenerateResource_NestedAttributes(t *testing.T) {
+	stubOptionalityDocument(t, nestedAttributeTestDocument)
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    nestedAttributeTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.NotContains(t, generated, "dynamic")
+	assert.Regexp(t, `network\s+= var.resource_network`, generated)
+	assert.Regexp(t, `rule\s+= var.resource_rule`, generated)
+	assert.Regexp(t, `labels\s+= var.resource_labels`, generated)
+	assert.Regexp(t, `type\s+= object\(\{\s+public\s+= optional\(bool\)\s+subnet_id\s+= string\s+}\)`, generated)
+	assert.Regexp(t, `ports\s+= optional\(set\(object\(\{\s+from\s+= number\s+to\s+= optional\(number\)`, generated)
+	assert.Regexp(t, `type\s+= map\(object\(\{\s+value = string\s+}\)\)`, generated)
+	assert.NotRegexp(t, `\bid\s+=`, generated)
+	assert.Contains(t, generated, "The network of the resource.")
+	assert.Contains(t, generated, "- `subnet_id` - The subnet.")
+	assert.Contains(t, generated, "- `priority` - (Required) The priority of the rule.")
+	assert.Contains(t, generated, "`ports` supports the following:")
+}
+
+func TestGenerateResource_NestedAttributeDescriptionPrefersDocument(t *testing.T) {
+	stubOptionalityDocument(t, `## Arguments Reference
+
+* `+"`network`"+` - (Optional) The documented network of the resource.
+
+## Attributes Reference
+`)
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    nestedAttributeTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.Contains(t, generated, "(Optional) The documented network of the resource.")
+	assert.NotContains(t, generated, "The network of the resource.")
+}
+
+func TestGenerateResource_NestedAttributesUniVariable(t *testing.T) {
+	stubOptionalityDocument(t, nestedAttributeTestDocument)
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		Mode:            UniVariable,
+		SchemaSource:    nestedAttributeTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.NotContains(t, generated, "dynamic")
+	assert.Regexp(t, `network\s+= var.resource.network`, generated)
+	assert.Regexp(t, `network\s+= optional\(object\(\{\s+public\s+= optional\(bool\)`, generated)
+	assert.Contains(t, generated, "`rule` supports the following:")
+}
+
+func TestCheckDocument_NestedAttributes(t *testing.T) {
+	stubOptionalityDocument(t, nestedAttributeTestDocument)
+	report, err := CheckDocument(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    nestedAttributeTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.NotContains(t, report.MissingInSchema, "rule.priority")
+	assert.Contains(t, report.MissingInDocument, "rule.ports.from")
+}
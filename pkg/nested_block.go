@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
 )
 
 var _ block = &nestedBlock{}
@@ -17,6 +18,9 @@ type nestedBlock struct {
 	parent block
 	attrs  []*attribute
 	nbs    []*nestedBlock
+	// restoredType is the type of the attribute the block is restored from, see restoreToNestedBlockSchema, cty.NilType
+	// for the nested blocks of the schema.
+	restoredType cty.Type
 }
 
 func (n *nestedBlock) schemaBlock() *tfjson.SchemaBlock {
@@ -46,6 +50,12 @@ func (n *nestedBlock) blockReadOnly() bool {
 	return true
 }
 
+// optionalityGuessed reports whether the schema doesn't tell which arguments of n are optional: n is restored from an
+// attribute whose object type has no optional attribute metadata, so all of them are assumed required.
+func (n *nestedBlock) optionalityGuessed() bool {
+	return n.restoredType != cty.NilType && len(restoredObjectType(n.restoredType).OptionalAttributes()) == 0
+}
+
 func (n *nestedBlock) NestingMode() tfjson.SchemaNestingMode {
 	return n.SchemaBlockType.NestingMode
 }
@@ -112,10 +122,14 @@ func (n *nestedBlock) generateDynamicBlock(iterator string) string {
 		obj = iterator
 	}
 	hcl.WriteString(fmt.Sprintf("dynamic \"%s\" {\n", n.name))
-	if n.maxItems() == 1 || n.NestingMode() == tfjson.SchemaNestingModeSingle {
-		hcl.WriteString(fmt.Sprintf("  for_each = %s == null ? [] : [%s]\n", obj, obj))
-	} else if n.SchemaBlockType.NestingMode == tfjson.SchemaNestingModeMap {
+	if n.NestingMode() == tfjson.SchemaNestingModeMap {
 		hcl.WriteString(fmt.Sprintf("  for_each = %s == null ? {} : %s\n", obj, obj))
+		if n.restoredType == cty.NilType {
+			// the keys of the map are the labels of the blocks
+			hcl.WriteString(fmt.Sprintf("  labels   = [%s.key]\n", n.name))
+		}
+	} else if singleObject(n) {
+		hcl.WriteString(fmt.Sprintf("  for_each = %s == null ? [] : [%s]\n", obj, obj))
 	} else {
 		hcl.WriteString(fmt.Sprintf("  for_each = %s == null ? [] : %s\n", obj, obj))
 	}
@@ -149,7 +163,9 @@ func (n *nestedBlock) generateHCLNestedBlocks(iterator string) string {
 
 func (n *nestedBlock) isDynamic() bool {
 	switch n.SchemaBlockType.NestingMode {
-	case tfjson.SchemaNestingModeList, tfjson.SchemaNestingModeSingle, tfjson.SchemaNestingModeGroup:
+	case tfjson.SchemaNestingModeGroup:
+		return false
+	case tfjson.SchemaNestingModeList, tfjson.SchemaNestingModeSingle:
 		return n.minItems() == 0
 	case tfjson.SchemaNestingModeSet, tfjson.SchemaNestingModeMap:
 		return true
@@ -157,23 +173,47 @@ func (n *nestedBlock) isDynamic() bool {
 	panic(fmt.Sprintf("unexpected nesting mode: %s", n.SchemaBlockType.NestingMode))
 }
 
+// alwaysPresent reports whether the block is present even when it's not configured, like a group block.
+func (n *nestedBlock) alwaysPresent() bool {
+	return n.NestingMode() == tfjson.SchemaNestingModeGroup
+}
+
+// requiresArguments reports whether b has a required attribute or nested block, so it can't be left empty.
+func requiresArguments(b block) bool {
+	for _, a := range b.attributes() {
+		if a.Required && !a.skipAttribute() {
+			return true
+		}
+	}
+	for _, nb := range b.nestedBlocks() {
+		if nb.minItems() > 0 || nb.alwaysPresent() && requiresArguments(nb) {
+			return true
+		}
+	}
+	return false
+}
+
 func generateVariableDescription(n block, descriptions map[string]argumentDescription) hclwrite.Tokens {
 	descriptionTokens := newTokens()
 	for _, attr := range n.attributes() {
 		desc := ""
-		nb, isNestedBlock := n.(*nestedBlock)
-		key := attr.name
-		if isNestedBlock {
-			key = fmt.Sprintf("%s.%s", nb.name, attr.name)
-		}
-		fetchedDesc, ok := descriptions[key]
-		if ok {
-			desc = fetchedDesc.desc
-		} else {
-			desc = descriptions[attr.name].desc
+		if d, ok := describe(descriptions, documentPath(n, attr.name)); ok {
+			desc = d.desc
 		}
 		descriptionTokens.ident(fmt.Sprintf("- `%s` - %s", attr.name, desc), 2).newLine()
 	}
+	for _, attr := range n.attributes() {
+		if attr.AttributeNestedType == nil {
+			continue
+		}
+		descriptionTokens.
+			newLine().
+			ident("---", 2).
+			newLine().
+			ident(fmt.Sprintf("`%s` supports the following:", attr.name), 2).
+			newLine().
+			rawTokens(nestedAttributeDescription(documentPath(n, attr.name), attr.AttributeNestedType, descriptions).Tokens)
+	}
 	for _, nb := range n.nestedBlocks() {
 		descriptionTokens.
 			newLine().
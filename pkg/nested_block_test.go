@@ -292,7 +292,7 @@ func TestGenerateVariableType_SimpleObject(t *testing.T) {
 		t.Run(c.nestedBlockName, func(t *testing.T) {
 			input := aksSchema.Block.NestedBlocks[c.nestedBlockName]
 			require.NoError(t, err)
-			actual := strings.Replace(generateVariableType(newNestedBlock(r, c.nestedBlockName, input), true), " ", "", -1)
+			actual := strings.Replace(generateVariableType(newNestedBlock(r, c.nestedBlockName, input), true, nil), " ", "", -1)
 			assert.Equal(t, strings.Replace(c.expected, " ", "", -1), actual)
 		})
 	}
@@ -301,13 +301,14 @@ func TestGenerateVariableType_SimpleObject(t *testing.T) {
 func TestGenerateVariableType_RequiredObject(t *testing.T) {
 	input := azurermschema.Resources["azurerm_kubernetes_cluster"]
 	resourceBlock, _ := newResourceBlock("azurerm_kubernetes_cluster", input, Config{})
-	actual := strings.Replace(generateVariableType(resourceBlock, true), " ", "", -1)
+	actual := strings.Replace(generateVariableType(resourceBlock, true, nil), " ", "", -1)
 	assert.Contains(t, actual, "default_node_pool=object({")
 }
 
 func TestGenerateDynamicBlockForAzurermTimeouts(t *testing.T) {
 	code, err := GenerateResource(NewResourceGenerateCommand("azurerm_storage_table", Config{
-		Mode: MultipleVariables,
+		SchemaSource: testSchemaSource,
+		Mode:         MultipleVariables,
 	}, nil))
 	require.NoError(t, err)
 	assert.Contains(t, code, "for_each = var.storage_table_timeouts == null ? [] : [var.storage_table_timeouts]")
@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func nestingModeTestBlock(mode tfjson.SchemaNestingMode, required bool) *tfjson.SchemaBlockType {
+	return &tfjson.SchemaBlockType{
+		NestingMode: mode,
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"value": {AttributeType: cty.String, Required: required, Optional: !required},
+			},
+		},
+	}
+}
+
+// nestingModeTestSchemas has a nested block of every nesting mode, and a group block with a required argument.
+var nestingModeTestSchemas = versionedSchemaSource{
+	"1.0.0": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name": {AttributeType: cty.String, Required: true},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"single":         nestingModeTestBlock(tfjson.SchemaNestingModeSingle, true),
+				"list":           nestingModeTestBlock(tfjson.SchemaNestingModeList, true),
+				"set":            nestingModeTestBlock(tfjson.SchemaNestingModeSet, true),
+				"map":            nestingModeTestBlock(tfjson.SchemaNestingModeMap, true),
+				"group":          nestingModeTestBlock(tfjson.SchemaNestingModeGroup, false),
+				"required_group": nestingModeTestBlock(tfjson.SchemaNestingModeGroup, true),
+			},
+		},
+	},
+}
+
+func generateNestingModeTestResource(t *testing.T, mode GenerateMode) string {
+	stubOptionalityDocument(t, "")
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		Mode:            mode,
+		SchemaSource:    nestingModeTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	return generated
+}
+
+func TestGenerateResource_NestingModes(t *testing.T) {
+	generated := generateNestingModeTestResource(t, MultipleVariables)
+	assert.Regexp(t, `variable "resource_single" {\s+type\s+= object\(\{`, generated)
+	assert.Regexp(t, `variable "resource_list" {\s+type\s+= list\(object\(\{`, generated)
+	assert.Regexp(t, `variable "resource_set" {\s+type\s+= set\(object\(\{`, generated)
+	assert.Regexp(t, `variable "resource_map" {\s+type\s+= map\(object\(\{`, generated)
+	assert.Regexp(t, `variable "resource_group" {\s+type\s+= object\(\{\s+value = optional\(string\)\s+}\)\s+default\s+= {}\s+nullable\s+= false`, generated)
+	assert.Regexp(t, `variable "resource_required_group" {\s+type\s+= object\(\{\s+value = string\s+}\)\s+nullable\s+= false`, generated)
+
+	assert.Regexp(t, `dynamic "single" {\s+for_each = var.resource_single == null \? \[\] : \[var.resource_single\]`, generated)
+	assert.Regexp(t, `dynamic "list" {\s+for_each = var.resource_list == null \? \[\] : var.resource_list\s`, generated)
+	assert.Regexp(t, `dynamic "set" {\s+for_each = var.resource_set == null \? \[\] : var.resource_set\s`, generated)
+	assert.Regexp(t, `dynamic "map" {\s+for_each = var.resource_map == null \? {} : var.resource_map\s+labels\s+= \[map.key\]`, generated)
+	assert.Regexp(t, `dynamic "group" {\s+for_each = \[var.resource_group\]`, generated)
+	assert.Regexp(t, `dynamic "required_group" {\s+for_each = \[var.resource_required_group\]`, generated)
+}
+
+func TestGenerateResource_NestingModesUniVariable(t *testing.T) {
+	generated := generateNestingModeTestResource(t, UniVariable)
+	assert.Regexp(t, `single\s+= optional\(object\(\{`, generated)
+	assert.Regexp(t, `list\s+= optional\(list\(object\(\{`, generated)
+	assert.Regexp(t, `set\s+= optional\(set\(object\(\{`, generated)
+	assert.Regexp(t, `map\s+= optional\(map\(object\(\{\s+value = string\s+}\)\)\)`, generated)
+	assert.Regexp(t, `group\s+= optional\(object\(\{\s+value = optional\(string\)\s+}\), {}\)`, generated)
+	assert.Regexp(t, `required_group\s+= object\(\{\s+value = string\s+}\)`, generated)
+
+	assert.Regexp(t, `dynamic "map" {\s+for_each = var.resource.map == null \? {} : var.resource.map\s+labels\s+= \[map.key\]`, generated)
+	assert.Regexp(t, `dynamic "group" {\s+for_each = \[var.resource.group\]`, generated)
+}
+
+func TestSingleObject(t *testing.T) {
+	r, err := newResourceBlock("fake_resource", nestingModeTestSchemas["1.0.0"], Config{})
+	require.NoError(t, err)
+	expected := map[string]bool{
+		"single":         true,
+		"list":           false,
+		"set":            false,
+		"map":            false,
+		"group":          true,
+		"required_group": true,
+	}
+	for _, nb := range r.nestedBlocks() {
+		assert.Equal(t, expected[nb.name], singleObject(nb), nb.name)
+	}
+}
@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"regexp"
+)
+
+// optionalityRegex matches the marker descriptions start with, e.g. "(Optional)" or "(Optional, Beta)".
+var optionalityRegex = regexp.MustCompile(`^\((Optional|Required)\b`)
+
+// documentedOptionality returns "Optional" or "Required" as the document marks the argument at path, empty if it
+// doesn't.
+func documentedOptionality(descriptions map[string]argumentDescription, path string) string {
+	d, ok := describe(descriptions, path)
+	if !ok {
+		return ""
+	}
+	if m := optionalityRegex.FindStringSubmatch(d.desc); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// restoreOptionality makes the arguments of restored blocks whose optionality the schema doesn't tell optional when
+// their documentation says so. It returns the paths of the arguments it still assumes required, documented as neither.
+func restoreOptionality(b block, descriptions map[string]argumentDescription) []string {
+	var guessed []string
+	for _, nb := range b.nestedBlocks() {
+		if nb.optionalityGuessed() {
+			for _, a := range nb.attributes() {
+				path := documentPath(nb, a.name)
+				switch documentedOptionality(descriptions, path) {
+				case "Optional":
+					a.Optional, a.Required = true, false
+				case "":
+					guessed = append(guessed, path)
+				}
+			}
+			for _, child := range nb.nestedBlocks() {
+				path := documentPath(nb, child.name)
+				switch documentedOptionality(descriptions, path) {
+				case "Optional":
+					child.MinItems = 0
+				case "":
+					guessed = append(guessed, path)
+				}
+			}
+		}
+		guessed = append(guessed, restoreOptionality(nb, descriptions)...)
+	}
+	return guessed
+}
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// optionalityTestSchemas has blocks the provider declares as attributes, `container` without optional attribute
+// metadata and `volume` with it.
+var optionalityTestSchemas = versionedSchemaSource{
+	"1.0.0": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name": {AttributeType: cty.String, Required: true},
+				"container": {AttributeType: cty.List(cty.Object(map[string]cty.Type{
+					"image":    cty.String,
+					"commands": cty.List(cty.String),
+					"cpu":      cty.Number,
+					"port": cty.List(cty.Object(map[string]cty.Type{
+						"number":   cty.Number,
+						"protocol": cty.String,
+					})),
+				})), Optional: true},
+				"volume": {AttributeType: cty.Set(cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+					"name":      cty.String,
+					"read_only": cty.Bool,
+				}, []string{"read_only"})), Optional: true},
+			},
+		},
+	},
+}
+
+const optionalityTestDocument = `## Arguments Reference
+
+* ` + "`name`" + ` - (Required) The name.
+
+* ` + "`container`" + ` - (Optional) One or more container blocks.
+
+* ` + "`volume`" + ` - (Optional) One or more volume blocks.
+
+A ` + "`container`" + ` block supports the following:
+
+* ` + "`image`" + ` - (Required) The image.
+
+* ` + "`commands`" + ` - (Optional) The commands.
+
+* ` + "`cpu`" + ` - The CPU cores.
+
+* ` + "`port`" + ` - (Optional) One or more port blocks.
+
+A ` + "`port`" + ` block supports the following:
+
+* ` + "`number`" + ` - (Required) The port number.
+
+* ` + "`protocol`" + ` - (Optional) The protocol.
+
+## Attributes Reference
+`
+
+func stubOptionalityDocument(t *testing.T, document string) {
+	previous := content
+	content = func(string, Config) (string, error) {
+		return document, nil
+	}
+	t.Cleanup(func() {
+		content = previous
+	})
+}
+
+func TestRestoreToNestedBlockSchema_OptionalAttributesOfCollectionElements(t *testing.T) {
+	actual := restoreToNestedBlockSchema(optionalityTestSchemas["1.0.0"].Block.Attributes["volume"])
+	assert.True(t, actual.Block.Attributes["read_only"].Optional)
+	assert.False(t, actual.Block.Attributes["read_only"].Required)
+	assert.True(t, actual.Block.Attributes["name"].Required)
+}
+
+func TestGenerateResource_OptionalityFromDocument(t *testing.T) {
+	stubOptionalityDocument(t, optionalityTestDocument)
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		Mode:            UniVariable,
+		SchemaSource:    optionalityTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.Regexp(t, `commands\s+= optional\(list\(string\)\)`, generated)
+	assert.Regexp(t, `cpu\s+= number`, generated)
+	assert.Regexp(t, `image\s+= string`, generated)
+	assert.Regexp(t, `port\s+= optional\(list\(object\(\{\s+number\s+= number\s+protocol\s+= optional\(string\)`, generated)
+	assert.Regexp(t, `name\s+= string\s+read_only\s+= optional\(bool\)`, generated)
+}
+
+func TestCheckDocument_AssumedRequired(t *testing.T) {
+	stubOptionalityDocument(t, optionalityTestDocument)
+	report, err := CheckDocument(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    optionalityTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"container.cpu"}, report.AssumedRequired)
+	assert.Contains(t, report.String(), "Arguments of fake_resource assumed required, the schema and documentation don't tell if they're optional:\n  - container.cpu\n")
+}
+
+func TestCheckDocument_AssumedRequiredWithoutDocument(t *testing.T) {
+	stubOptionalityDocument(t, "")
+	report, err := CheckDocument(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    optionalityTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"container.commands", "container.cpu", "container.image", "container.port", "container.port.number", "container.port.protocol"}, report.AssumedRequired)
+	assert.NotContains(t, report.String(), "doesn't match its schema")
+}
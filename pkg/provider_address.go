@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultRegistryHost = "registry.terraform.io"
+
+// providerAddress is a fully qualified provider source address, e.g. registry.terraform.io/hashicorp/azurerm.
+type providerAddress struct {
+	hostname     string
+	namespace    string
+	providerType string
+}
+
+func (a providerAddress) String() string {
+	return fmt.Sprintf("%s/%s/%s", a.hostname, a.namespace, a.providerType)
+}
+
+// parseProviderSource parses a provider source address in the same forms Terraform accepts in `required_providers`:
+// `TYPE`, `NAMESPACE/TYPE` or `HOSTNAME/NAMESPACE/TYPE`. Omitted parts are left empty.
+func parseProviderSource(source string) (providerAddress, error) {
+	segments := strings.Split(strings.TrimSpace(source), "/")
+	for _, s := range segments {
+		if s == "" {
+			return providerAddress{}, fmt.Errorf("invalid provider source address %q", source)
+		}
+	}
+	switch len(segments) {
+	case 1:
+		return providerAddress{providerType: segments[0]}, nil
+	case 2:
+		return providerAddress{namespace: segments[0], providerType: segments[1]}, nil
+	case 3:
+		return providerAddress{hostname: strings.ToLower(segments[0]), namespace: segments[1], providerType: segments[2]}, nil
+	}
+	return providerAddress{}, fmt.Errorf("invalid provider source address %q, expected [HOSTNAME/]NAMESPACE/TYPE", source)
+}
+
+// resolveProviderAddress works out which provider serves resourceType. Config.ProviderSource wins over
+// Config.ProviderNamespace, missing hostname falls back to the Terraform Registry and missing namespace is discovered there.
+func resolveProviderAddress(resourceType string, cfg Config) (providerAddress, error) {
+	addr := providerAddress{
+		providerType: resourceVendor(resourceType),
+		namespace:    cfg.ProviderNamespace,
+	}
+	if cfg.ProviderSource != "" {
+		source, err := parseProviderSource(cfg.ProviderSource)
+		if err != nil {
+			return providerAddress{}, err
+		}
+		addr.providerType = source.providerType
+		if source.namespace != "" {
+			addr.namespace = source.namespace
+		}
+		addr.hostname = source.hostname
+	}
+	if addr.hostname == "" {
+		addr.hostname = defaultRegistryHost
+	}
+	if addr.namespace != "" {
+		return addr, nil
+	}
+	ns, err := discoverNamespace(addr.providerType)
+	if err != nil {
+		return providerAddress{}, err
+	}
+	addr.namespace = ns
+	return addr, nil
+}
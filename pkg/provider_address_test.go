@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProviderSource(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected providerAddress
+	}{
+		{
+			source:   "azurerm",
+			expected: providerAddress{providerType: "azurerm"},
+		},
+		{
+			source:   "Azure/azapi",
+			expected: providerAddress{namespace: "Azure", providerType: "azapi"},
+		},
+		{
+			source:   "App.Terraform.io/acme/internalcloud",
+			expected: providerAddress{hostname: "app.terraform.io", namespace: "acme", providerType: "internalcloud"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			actual, err := parseProviderSource(c.source)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestParseProviderSource_Invalid(t *testing.T) {
+	for _, source := range []string{"", "acme//internalcloud", "a/b/c/d"} {
+		t.Run(source, func(t *testing.T) {
+			_, err := parseProviderSource(source)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolveProviderAddress(t *testing.T) {
+	cases := []struct {
+		name         string
+		resourceType string
+		cfg          Config
+		expected     string
+	}{
+		{
+			name:         "default namespace",
+			resourceType: "azapi_resource",
+			expected:     "registry.terraform.io/Azure/azapi",
+		},
+		{
+			name:         "namespace from config",
+			resourceType: "datadog_monitor",
+			cfg:          Config{ProviderNamespace: "DataDog"},
+			expected:     "registry.terraform.io/DataDog/datadog",
+		},
+		{
+			name:         "source overrides namespace",
+			resourceType: "internalcloud_network",
+			cfg:          Config{ProviderNamespace: "hashicorp", ProviderSource: "app.terraform.io/acme/internalcloud"},
+			expected:     "app.terraform.io/acme/internalcloud",
+		},
+		{
+			name:         "source without hostname",
+			resourceType: "aws_instance",
+			cfg:          Config{ProviderSource: "hashicorp/aws"},
+			expected:     "registry.terraform.io/hashicorp/aws",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addr, err := resolveProviderAddress(c.resourceType, c.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, addr.String())
+		})
+	}
+}
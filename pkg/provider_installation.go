@@ -0,0 +1,431 @@
+package pkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+const (
+	directInstallation           = "direct"
+	networkMirrorInstallation    = "network_mirror"
+	filesystemMirrorInstallation = "filesystem_mirror"
+)
+
+// installationMethod is one method in the `provider_installation` block of the Terraform CLI configuration,
+// see https://developer.hashicorp.com/terraform/cli/config/config-file#provider-installation.
+type installationMethod struct {
+	kind string
+	// location is the mirror's url for network_mirror and its directory for filesystem_mirror.
+	location string
+	include  []string
+	exclude  []string
+}
+
+type installationMethodBlock struct {
+	Path    string   `hcl:"path"`
+	Url     string   `hcl:"url"`
+	Include []string `hcl:"include"`
+	Exclude []string `hcl:"exclude"`
+}
+
+// decodeProviderInstallation decodes the methods of a `provider_installation` block in their declared order.
+// `dev_overrides` is left to Terraform.
+func decodeProviderInstallation(block *ast.ObjectItem) ([]installationMethod, error) {
+	body, ok := block.Val.(*ast.ObjectType)
+	if !ok {
+		return nil, fmt.Errorf("provider_installation must be a block")
+	}
+	methods := make([]installationMethod, 0, len(body.List.Items))
+	for _, item := range body.List.Items {
+		if len(item.Keys) != 1 {
+			return nil, fmt.Errorf("invalid provider_installation method at %s", item.Pos())
+		}
+		kind, _ := item.Keys[0].Token.Value().(string)
+		switch kind {
+		case directInstallation, networkMirrorInstallation, filesystemMirrorInstallation:
+		case "dev_overrides":
+			continue
+		default:
+			return nil, fmt.Errorf("unknown provider installation method %q at %s", kind, item.Pos())
+		}
+		var mb installationMethodBlock
+		if err := hcl.DecodeObject(&mb, item.Val); err != nil {
+			return nil, fmt.Errorf("invalid %s at %s: %w", kind, item.Pos(), err)
+		}
+		m := installationMethod{
+			kind:    kind,
+			include: mb.Include,
+			exclude: mb.Exclude,
+		}
+		switch kind {
+		case networkMirrorInstallation:
+			if mb.Url == "" {
+				return nil, fmt.Errorf("network_mirror at %s requires url", item.Pos())
+			}
+			m.location = mb.Url
+		case filesystemMirrorInstallation:
+			if mb.Path == "" {
+				return nil, fmt.Errorf("filesystem_mirror at %s requires path", item.Pos())
+			}
+			m.location = mb.Path
+		}
+		methods = append(methods, m)
+	}
+	return methods, nil
+}
+
+// matches reports whether the method's include and exclude patterns select addr.
+func (m installationMethod) matches(addr providerAddress) bool {
+	included := len(m.include) == 0
+	for _, pattern := range m.include {
+		if providerPatternMatches(pattern, addr) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range m.exclude {
+		if providerPatternMatches(pattern, addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m installationMethod) source(addr providerAddress, cliCfg *cliConfig) (providerSource, error) {
+	switch m.kind {
+	case networkMirrorInstallation:
+		return newNetworkMirror(m.location, cliCfg)
+	case filesystemMirrorInstallation:
+		return filesystemMirror{dir: m.location}, nil
+	}
+	return newRegistryClient(addr.hostname)
+}
+
+// providerPatternMatches matches addr against a provider source pattern where each part may be `*`.
+func providerPatternMatches(pattern string, addr providerAddress) bool {
+	p, err := parseProviderSource(pattern)
+	if err != nil {
+		return false
+	}
+	if p.hostname == "" {
+		p.hostname = defaultRegistryHost
+	}
+	if p.namespace == "" {
+		p.namespace = "hashicorp"
+	}
+	match := func(p, v string) bool {
+		return p == "*" || strings.EqualFold(p, v)
+	}
+	return match(p.hostname, addr.hostname) && match(p.namespace, addr.namespace) && match(p.providerType, addr.providerType)
+}
+
+var _ providerSource = installationSource{}
+
+// installationSource installs a provider the way `terraform init` would with the configured `provider_installation`:
+// versions are collected from every method that matches the provider, packages come from the first method offering the version.
+type installationSource struct {
+	sources []providerSource
+}
+
+func newInstallationSource(addr providerAddress, methods []installationMethod, cliCfg *cliConfig) (installationSource, error) {
+	var s installationSource
+	for _, m := range methods {
+		if !m.matches(addr) {
+			continue
+		}
+		source, err := m.source(addr, cliCfg)
+		if err != nil {
+			return s, err
+		}
+		s.sources = append(s.sources, source)
+	}
+	if len(s.sources) == 0 {
+		return s, fmt.Errorf("no provider_installation method in the Terraform CLI config matches provider %s", addr)
+	}
+	return s, nil
+}
+
+func (s installationSource) availableVersions(addr providerAddress) ([]string, error) {
+	var versions []string
+	seen := make(map[string]bool)
+	for _, source := range s.sources {
+		vs, err := source.availableVersions(addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vs {
+			if !seen[v] {
+				seen[v] = true
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions, nil
+}
+
+func (s installationSource) packageMeta(addr providerAddress, version, goos, goarch string) (*packageMeta, error) {
+	for _, source := range s.sources {
+		vs, err := source.availableVersions(addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vs {
+			if v == version {
+				return source.packageMeta(addr, version, goos, goarch)
+			}
+		}
+	}
+	return nil, fmt.Errorf("provider %s %s is not available from any configured installation method", addr, version)
+}
+
+var _ providerSource = &networkMirror{}
+
+// networkMirror implements the provider network mirror protocol,
+// see https://developer.hashicorp.com/terraform/internals/provider-network-mirror-protocol.
+type networkMirror struct {
+	baseUrl *url.URL
+	token   string
+}
+
+func newNetworkMirror(mirrorUrl string, cliCfg *cliConfig) (*networkMirror, error) {
+	u, err := url.Parse(mirrorUrl)
+	if err != nil || u.Scheme != "https" {
+		return nil, fmt.Errorf("network_mirror url must be an absolute https url, got %q", mirrorUrl)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &networkMirror{
+		baseUrl: u,
+		token:   cliCfg.token(u.Host),
+	}, nil
+}
+
+func (n *networkMirror) availableVersions(addr providerAddress) ([]string, error) {
+	var index struct {
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	found, err := n.getJson(n.providerUrl(addr, "index.json"), &index)
+	if err != nil || !found {
+		return nil, err
+	}
+	var versions []string
+	for v := range index.Versions {
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (n *networkMirror) packageMeta(addr providerAddress, version, goos, goarch string) (*packageMeta, error) {
+	versionUrl := n.providerUrl(addr, version+".json")
+	var resp struct {
+		Archives map[string]struct {
+			Url    string   `json:"url"`
+			Hashes []string `json:"hashes"`
+		} `json:"archives"`
+	}
+	found, err := n.getJson(versionUrl, &resp)
+	if err != nil {
+		return nil, err
+	}
+	archive, ok := resp.Archives[fmt.Sprintf("%s_%s", goos, goarch)]
+	if !found || !ok {
+		return nil, fmt.Errorf("network mirror %s has no package of provider %s %s for %s_%s", n.baseUrl, addr, version, goos, goarch)
+	}
+	base, _ := url.Parse(versionUrl)
+	ref, err := url.Parse(archive.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive url %q of provider %s on network mirror %s: %w", archive.Url, addr, n.baseUrl, err)
+	}
+	downloadUrl := base.ResolveReference(ref)
+	return &packageMeta{
+		OS:          goos,
+		Arch:        goarch,
+		Filename:    path.Base(downloadUrl.Path),
+		DownloadUrl: downloadUrl.String(),
+		Hashes:      archive.Hashes,
+	}, nil
+}
+
+func (n *networkMirror) providerUrl(addr providerAddress, file string) string {
+	return n.baseUrl.ResolveReference(&url.URL{Path: path.Join(addr.hostname, addr.namespace, addr.providerType, file)}).String()
+}
+
+// getJson returns false without error when the mirror doesn't have the document.
+func (n *networkMirror) getJson(u string, v any) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return false, err
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	resp, err := registryHttpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to query network mirror %s: %w", n.baseUrl, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("network mirror %s returned status %d for %s", n.baseUrl, resp.StatusCode, u)
+	}
+	return true, json.NewDecoder(resp.Body).Decode(v)
+}
+
+var _ providerSource = filesystemMirror{}
+
+// filesystemMirror reads providers from a local directory in either the packed layout
+// (`HOSTNAME/NAMESPACE/TYPE/terraform-provider-TYPE_VERSION_TARGET.zip`)
+// or the unpacked layout (`HOSTNAME/NAMESPACE/TYPE/VERSION/TARGET/`).
+type filesystemMirror struct {
+	dir string
+}
+
+func (f filesystemMirror) providerDir(addr providerAddress) string {
+	return filepath.Join(f.dir, addr.hostname, addr.namespace, addr.providerType)
+}
+
+func (f filesystemMirror) availableVersions(addr providerAddress) ([]string, error) {
+	entries, err := os.ReadDir(f.providerDir(addr))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filesystem mirror %s: %w", f.dir, err)
+	}
+	var versions []string
+	seen := make(map[string]bool)
+	prefix := fmt.Sprintf("terraform-provider-%s_", addr.providerType)
+	for _, e := range entries {
+		version := e.Name()
+		if !e.IsDir() {
+			if !strings.HasPrefix(version, prefix) || !strings.HasSuffix(version, ".zip") {
+				continue
+			}
+			// terraform-provider-TYPE_VERSION_OS_ARCH.zip
+			segments := strings.Split(strings.TrimSuffix(strings.TrimPrefix(version, prefix), ".zip"), "_")
+			if len(segments) != 3 {
+				continue
+			}
+			version = segments[0]
+		}
+		if !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+func (f filesystemMirror) packageMeta(addr providerAddress, version, goos, goarch string) (*packageMeta, error) {
+	filename := fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", addr.providerType, version, goos, goarch)
+	candidates := []string{
+		filepath.Join(f.providerDir(addr), filename),
+		filepath.Join(f.providerDir(addr), version, fmt.Sprintf("%s_%s", goos, goarch)),
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err != nil {
+			continue
+		}
+		abs, err := filepath.Abs(c)
+		if err != nil {
+			return nil, err
+		}
+		return &packageMeta{
+			OS:          goos,
+			Arch:        goarch,
+			Filename:    filename,
+			DownloadUrl: localFileUrl(abs),
+		}, nil
+	}
+	return nil, fmt.Errorf("filesystem mirror %s has no package of provider %s %s for %s_%s", f.dir, addr, version, goos, goarch)
+}
+
+func localFileUrl(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func localFilePath(u *url.URL) string {
+	p := u.Path
+	if runtime.GOOS == "windows" {
+		p = strings.TrimPrefix(p, "/")
+	}
+	return filepath.FromSlash(p)
+}
+
+// readLocalPackage returns the zip archive of a provider package in a filesystem mirror,
+// zipping the directory of an unpacked package on the fly.
+func readLocalPackage(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return os.ReadFile(filepath.Clean(path))
+	}
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		header.Method = zip.Deflate
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(filepath.Clean(p))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = src.Close()
+		}()
+		_, err = io.Copy(fw, src)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack provider directory %s: %w", path, err)
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var internalCloud = providerAddress{
+	hostname:     "example.com",
+	namespace:    "acme",
+	providerType: "internalcloud",
+}
+
+func TestCliConfig_ProviderInstallation(t *testing.T) {
+	setTestCliConfig(t, `
+provider_installation {
+  filesystem_mirror {
+    path    = "/usr/share/terraform/providers"
+    include = ["example.com/*/*"]
+  }
+  network_mirror {
+    url     = "https://mirror.example.com/providers/"
+    exclude = ["example.com/*/*"]
+  }
+  dev_overrides {
+    "hashicorp/null" = "/home/developer/null"
+  }
+}
+`)
+	cfg, err := loadCliConfig()
+	require.NoError(t, err)
+	require.Len(t, cfg.providerInstallation, 2)
+	assert.Equal(t, filesystemMirrorInstallation, cfg.providerInstallation[0].kind)
+	assert.Equal(t, "/usr/share/terraform/providers", cfg.providerInstallation[0].location)
+	assert.Equal(t, networkMirrorInstallation, cfg.providerInstallation[1].kind)
+	assert.Equal(t, "https://mirror.example.com/providers/", cfg.providerInstallation[1].location)
+}
+
+func TestCliConfig_NoProviderInstallation(t *testing.T) {
+	setTestCliConfig(t, "")
+	cfg, err := loadCliConfig()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.providerInstallation)
+}
+
+func TestInstallationMethod_Matches(t *testing.T) {
+	aws := providerAddress{hostname: defaultRegistryHost, namespace: "hashicorp", providerType: "aws"}
+	cases := []struct {
+		name     string
+		method   installationMethod
+		addr     providerAddress
+		expected bool
+	}{
+		{name: "no patterns", method: installationMethod{}, addr: aws, expected: true},
+		{name: "include hostname wildcard", method: installationMethod{include: []string{"example.com/*/*"}}, addr: internalCloud, expected: true},
+		{name: "include other hostname", method: installationMethod{include: []string{"example.com/*/*"}}, addr: aws, expected: false},
+		{name: "pattern without hostname means public registry", method: installationMethod{include: []string{"hashicorp/*"}}, addr: aws, expected: true},
+		{name: "excluded", method: installationMethod{exclude: []string{"example.com/acme/*"}}, addr: internalCloud, expected: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.method.matches(c.addr))
+		})
+	}
+}
+
+func TestNetworkMirror(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/providers/example.com/acme/internalcloud/index.json":
+			_, _ = io.WriteString(w, `{"versions":{"1.0.0":{},"1.1.0":{}}}`)
+		case "/providers/example.com/acme/internalcloud/1.1.0.json":
+			_, _ = io.WriteString(w, `{"archives":{"linux_amd64":{"url":"terraform-provider-internalcloud_1.1.0_linux_amd64.zip","hashes":["h1:abc"]}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	previous := registryHttpClient
+	registryHttpClient = ts.Client()
+	defer func() {
+		registryHttpClient = previous
+	}()
+
+	mirror, err := newNetworkMirror(ts.URL+"/providers", &cliConfig{})
+	require.NoError(t, err)
+	versions, err := mirror.availableVersions(internalCloud)
+	require.NoError(t, err)
+	sort.Strings(versions)
+	assert.Equal(t, []string{"1.0.0", "1.1.0"}, versions)
+
+	meta, err := mirror.packageMeta(internalCloud, "1.1.0", "linux", "amd64")
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/providers/example.com/acme/internalcloud/terraform-provider-internalcloud_1.1.0_linux_amd64.zip", meta.DownloadUrl)
+	assert.Equal(t, "terraform-provider-internalcloud_1.1.0_linux_amd64.zip", meta.Filename)
+
+	versions, err = mirror.availableVersions(providerAddress{hostname: defaultRegistryHost, namespace: "hashicorp", providerType: "aws"})
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestNetworkMirror_RequiresHttps(t *testing.T) {
+	_, err := newNetworkMirror("http://mirror.example.com/", &cliConfig{})
+	assert.Error(t, err)
+}
+
+func newTestFilesystemMirror(t *testing.T) filesystemMirror {
+	dir := t.TempDir()
+	providerDir := filepath.Join(dir, "example.com", "acme", "internalcloud")
+	require.NoError(t, os.MkdirAll(filepath.Join(providerDir, "1.1.0", "linux_amd64"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(providerDir, "1.1.0", "linux_amd64", "terraform-provider-internalcloud_v1.1.0"), []byte("binary"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(providerDir, "terraform-provider-internalcloud_1.0.0_linux_amd64.zip"), []byte("packed"), 0600))
+	return filesystemMirror{dir: dir}
+}
+
+func TestFilesystemMirror_AvailableVersions(t *testing.T) {
+	mirror := newTestFilesystemMirror(t)
+	versions, err := mirror.availableVersions(internalCloud)
+	require.NoError(t, err)
+	sort.Strings(versions)
+	assert.Equal(t, []string{"1.0.0", "1.1.0"}, versions)
+}
+
+func TestProviderRouter_ServesUnpackedFilesystemMirrorPackageAsZip(t *testing.T) {
+	mirror := newTestFilesystemMirror(t)
+	router := newProviderRouter(http.DefaultTransport)
+	router.route(internalCloud, mirror, nil)
+	meta, err := router.packageMeta(router.routes[routeKey("acme", "internalcloud")], "1.1.0", "linux", "amd64")
+	require.NoError(t, err)
+	assert.Equal(t, "terraform-provider-internalcloud_1.1.0_linux_amd64.zip", meta.Filename)
+	assert.True(t, strings.HasPrefix(meta.DownloadUrl, "file:///"))
+
+	req, err := http.NewRequest(http.MethodGet, meta.DownloadUrl, nil)
+	require.NoError(t, err)
+	resp, err := router.RoundTrip(req)
+	require.NoError(t, err)
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	require.Len(t, r.File, 1)
+	assert.Equal(t, "terraform-provider-internalcloud_v1.1.0", r.File[0].Name)
+}
+
+func TestProviderRouter_RefuseUnknownLocalFile(t *testing.T) {
+	router := newProviderRouter(http.DefaultTransport)
+	req, err := http.NewRequest(http.MethodGet, "file:///etc/passwd", nil)
+	require.NoError(t, err)
+	_, err = router.RoundTrip(req)
+	assert.Error(t, err)
+}
+
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, io.EOF
+}
+
+func TestInstallProviderRouter_RestoresDefaultClientTransport(t *testing.T) {
+	previous := http.DefaultClient.Transport
+	defer func() {
+		http.DefaultClient.Transport = previous
+	}()
+	original := stubTransport{}
+	http.DefaultClient.Transport = original
+
+	restoreOuter := installProviderRouter()
+	restoreInner := installProviderRouter()
+	assert.Same(t, getProviderRouter(), http.DefaultClient.Transport)
+	assert.Equal(t, original, getProviderRouter().transport())
+	restoreInner()
+	restoreInner()
+	assert.Same(t, getProviderRouter(), http.DefaultClient.Transport)
+	restoreOuter()
+	assert.Equal(t, original, http.DefaultClient.Transport)
+}
+
+func TestInstallationSource_PackageFromFirstMethodOfferingVersion(t *testing.T) {
+	mirror := newTestFilesystemMirror(t)
+	emptyMirror := filesystemMirror{dir: t.TempDir()}
+	source, err := newInstallationSource(internalCloud, []installationMethod{
+		{kind: filesystemMirrorInstallation, location: emptyMirror.dir},
+		{kind: filesystemMirrorInstallation, location: mirror.dir, include: []string{"example.com/*/*"}},
+		{kind: directInstallation, exclude: []string{"example.com/*/*"}},
+	}, &cliConfig{})
+	require.NoError(t, err)
+	assert.Len(t, source.sources, 2)
+	meta, err := source.packageMeta(internalCloud, "1.0.0", "linux", "amd64")
+	require.NoError(t, err)
+	assert.Equal(t, "terraform-provider-internalcloud_1.0.0_linux_amd64.zip", meta.Filename)
+}
+
+func TestInstallationSource_NoMatchingMethod(t *testing.T) {
+	_, err := newInstallationSource(internalCloud, []installationMethod{
+		{kind: directInstallation, exclude: []string{"example.com/*/*"}},
+	}, &cliConfig{})
+	assert.Error(t, err)
+}
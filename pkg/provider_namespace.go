@@ -0,0 +1,193 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// providerSearchUrl is the Terraform Registry's provider search API. It's not part of the registry protocol,
+// so namespaces are only discovered for providers on the Terraform Registry.
+var providerSearchUrl = "https://" + defaultRegistryHost + "/v2/providers"
+
+// wellKnownNamespaces are providers the registry search would resolve wrongly or ambiguously,
+// e.g. alicloud moved from hashicorp to aliyun.
+var wellKnownNamespaces = map[string]string{
+	"azapi":    "Azure",
+	"msgraph":  "microsoft",
+	"alicloud": "aliyun",
+}
+
+// fallbackNamespace is the namespace of providers discovery can't find, the one Terraform assumes for a provider
+// required by type only.
+const fallbackNamespace = "hashicorp"
+
+var tierRanks = map[string]int{
+	"official":  0,
+	"partner":   1,
+	"community": 2,
+}
+
+// AmbiguousProviderError is returned when the registry has more than one equally preferred provider for a type.
+// Candidates are namespaces; the one picked by the user can be saved with RememberProviderNamespace.
+type AmbiguousProviderError struct {
+	ProviderType string
+	Candidates   []string
+}
+
+func (e *AmbiguousProviderError) Error() string {
+	return fmt.Sprintf("found more than one provider named %s on %s: %s, please choose one with --provider-namespace or --provider-source",
+		e.ProviderType, defaultRegistryHost, strings.Join(e.Candidates, ", "))
+}
+
+type providerSearchResult struct {
+	Namespace string
+	Tier      string
+}
+
+// discoverNamespace works out the namespace of providerType on the Terraform Registry. The well-known table is
+// consulted first, then the namespace cache, then the filesystem mirrors of the configured `provider_installation`.
+// The registry's provider search, preferring official then partner tiers, is only used when providers may be
+// installed directly, so mirror-only setups never reach the registry. A namespace found by search is saved to the
+// cache; when nothing is found, the namespace is hashicorp.
+func discoverNamespace(providerType string) (string, error) {
+	if ns, ok := wellKnownNamespaces[providerType]; ok {
+		return ns, nil
+	}
+	cache := loadNamespaceCache()
+	if ns, ok := cache[providerType]; ok {
+		return ns, nil
+	}
+	cliCfg, err := loadCliConfig()
+	if err != nil {
+		return "", err
+	}
+	if mirrored := cliCfg.mirroredNamespaces(providerType); len(mirrored) > 1 {
+		return "", &AmbiguousProviderError{
+			ProviderType: providerType,
+			Candidates:   mirrored,
+		}
+	} else if len(mirrored) == 1 {
+		return mirrored[0], nil
+	}
+	if !cliCfg.installsDirectly() {
+		return fallbackNamespace, nil
+	}
+	results, err := searchProviders(providerType)
+	if err != nil || len(results) == 0 {
+		// the search isn't part of the registry protocol, a provider it can't find may still be installable
+		return fallbackNamespace, nil
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return tierRank(results[i].Tier) < tierRank(results[j].Tier)
+	})
+	var candidates []string
+	for _, r := range results {
+		if tierRank(r.Tier) == tierRank(results[0].Tier) {
+			candidates = append(candidates, r.Namespace)
+		}
+	}
+	if len(candidates) > 1 {
+		return "", &AmbiguousProviderError{
+			ProviderType: providerType,
+			Candidates:   candidates,
+		}
+	}
+	if err = RememberProviderNamespace(providerType, candidates[0]); err != nil {
+		return "", err
+	}
+	return candidates[0], nil
+}
+
+// RememberProviderNamespace saves namespace as the namespace of providerType in the namespace cache,
+// so later runs don't search the registry again.
+func RememberProviderNamespace(providerType, namespace string) error {
+	path := namespaceCachePath()
+	if path == "" {
+		return nil
+	}
+	cache := loadNamespaceCache()
+	cache[providerType] = namespace
+	content, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err = os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write namespace cache %s: %w", path, err)
+	}
+	return nil
+}
+
+// searchProviders returns the listed providers named exactly providerType.
+func searchProviders(providerType string) ([]providerSearchResult, error) {
+	u, err := url.Parse(providerSearchUrl)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("filter[name]", providerType)
+	query.Set("page[size]", "100")
+	u.RawQuery = query.Encode()
+	var resp struct {
+		Data []struct {
+			Attributes struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+				Tier      string `json:"tier"`
+				Unlisted  bool   `json:"unlisted"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	client := &registryClient{hostname: defaultRegistryHost}
+	if err = client.getJson(u.String(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to search provider %s on %s: %w", providerType, defaultRegistryHost, err)
+	}
+	var results []providerSearchResult
+	for _, d := range resp.Data {
+		if d.Attributes.Unlisted || !strings.EqualFold(d.Attributes.Name, providerType) {
+			continue
+		}
+		results = append(results, providerSearchResult{
+			Namespace: d.Attributes.Namespace,
+			Tier:      d.Attributes.Tier,
+		})
+	}
+	return results, nil
+}
+
+func tierRank(tier string) int {
+	if rank, ok := tierRanks[tier]; ok {
+		return rank
+	}
+	return len(tierRanks)
+}
+
+func loadNamespaceCache() map[string]string {
+	cache := make(map[string]string)
+	path := namespaceCachePath()
+	if path == "" {
+		return cache
+	}
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return cache
+	}
+	// A corrupted cache is only a cache miss.
+	_ = json.Unmarshal(content, &cache)
+	return cache
+}
+
+func namespaceCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "newres", "provider_namespaces.json")
+}
@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProviderSearch serves the registry's provider search and returns a counter of search requests.
+func newTestProviderSearch(t *testing.T) *int {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", home)
+	t.Setenv("LOCALAPPDATA", home)
+	searches := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches++
+		switch r.URL.Query().Get("filter[name]") {
+		case "datadog":
+			_, _ = io.WriteString(w, `{"data":[
+{"attributes":{"namespace":"someone","name":"datadog","tier":"community"}},
+{"attributes":{"namespace":"DataDog","name":"datadog","tier":"partner"}},
+{"attributes":{"namespace":"datadog-fork","name":"datadog-fork","tier":"partner"}}]}`)
+		case "github":
+			_, _ = io.WriteString(w, `{"data":[
+{"attributes":{"namespace":"integrations","name":"github","tier":"partner"}},
+{"attributes":{"namespace":"acme","name":"github","tier":"partner"}},
+{"attributes":{"namespace":"hashicorp","name":"github","tier":"official","unlisted":true}}]}`)
+		default:
+			_, _ = io.WriteString(w, `{"data":[]}`)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	previousClient, previousUrl := registryHttpClient, providerSearchUrl
+	registryHttpClient, providerSearchUrl = ts.Client(), ts.URL+"/v2/providers"
+	t.Cleanup(func() {
+		registryHttpClient, providerSearchUrl = previousClient, previousUrl
+	})
+	return &searches
+}
+
+func TestDiscoverNamespace_PreferHigherTierAndCacheIt(t *testing.T) {
+	searches := newTestProviderSearch(t)
+	ns, err := discoverNamespace("datadog")
+	require.NoError(t, err)
+	assert.Equal(t, "DataDog", ns)
+	ns, err = discoverNamespace("datadog")
+	require.NoError(t, err)
+	assert.Equal(t, "DataDog", ns)
+	assert.Equal(t, 1, *searches)
+}
+
+func TestDiscoverNamespace_WellKnown(t *testing.T) {
+	searches := newTestProviderSearch(t)
+	ns, err := discoverNamespace("alicloud")
+	require.NoError(t, err)
+	assert.Equal(t, "aliyun", ns)
+	assert.Equal(t, 0, *searches)
+}
+
+func TestDiscoverNamespace_Ambiguous(t *testing.T) {
+	newTestProviderSearch(t)
+	_, err := discoverNamespace("github")
+	var ambiguous *AmbiguousProviderError
+	require.ErrorAs(t, err, &ambiguous)
+	assert.Equal(t, []string{"integrations", "acme"}, ambiguous.Candidates)
+
+	require.NoError(t, RememberProviderNamespace("github", "integrations"))
+	ns, err := discoverNamespace("github")
+	require.NoError(t, err)
+	assert.Equal(t, "integrations", ns)
+}
+
+func TestDiscoverNamespace_NotFoundFallsBackToHashicorp(t *testing.T) {
+	newTestProviderSearch(t)
+	ns, err := discoverNamespace("internalcloud")
+	require.NoError(t, err)
+	assert.Equal(t, "hashicorp", ns)
+}
+
+func TestDiscoverNamespace_MirrorOnlyNeverSearches(t *testing.T) {
+	searches := newTestProviderSearch(t)
+	mirror := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(mirror, "registry.terraform.io", "DataDog", "datadog", "3.0.0"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(mirror, "registry.terraform.io", "acme", "github"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(mirror, "registry.terraform.io", "integrations", "github"), 0700))
+	config := filepath.Join(t.TempDir(), "test.tfrc")
+	require.NoError(t, os.WriteFile(config, []byte(fmt.Sprintf(`
+provider_installation {
+  filesystem_mirror {
+    path = %q
+  }
+  network_mirror {
+    url = "https://mirror.example.com/"
+  }
+}
+`, filepath.ToSlash(mirror))), 0600))
+	t.Setenv("TF_CLI_CONFIG_FILE", config)
+
+	ns, err := discoverNamespace("datadog")
+	require.NoError(t, err)
+	assert.Equal(t, "DataDog", ns)
+	_, err = discoverNamespace("github")
+	var ambiguous *AmbiguousProviderError
+	require.ErrorAs(t, err, &ambiguous)
+	assert.Equal(t, []string{"acme", "integrations"}, ambiguous.Candidates)
+	ns, err = discoverNamespace("internalcloud")
+	require.NoError(t, err)
+	assert.Equal(t, "hashicorp", ns)
+	assert.Equal(t, 0, *searches)
+}
@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// packageCheck is everything a downloaded provider package must match before tfpluginschema is allowed to execute it.
+type packageCheck struct {
+	addr    providerAddress
+	version string
+	meta    *packageMeta
+	// lockedHashes come from the dependency lock file when it locks the provider at the same version.
+	lockedHashes []string
+}
+
+// verify checks the archive against the registry's signed checksums, the hashes published by a network mirror and
+// the dependency lock file. Packages from a registry or network mirror that can't be checked against any of them are
+// refused; packages from a local filesystem mirror are trusted with a warning like Terraform does unless they are locked.
+func (c packageCheck) verify(archive []byte) error {
+	sum := sha256.Sum256(archive)
+	actual := hex.EncodeToString(sum[:])
+	verified := false
+	if c.meta.ShasumsUrl != "" {
+		expected, err := c.signedShasum()
+		if err != nil {
+			return c.fail("%s", err.Error())
+		}
+		if expected != actual {
+			return c.fail("checksum %s doesn't match %s in the signed SHA256SUMS", actual, expected)
+		}
+		verified = true
+	}
+	// the registry's shasum isn't signed, a mismatch refuses the package but a match verifies nothing.
+	if c.meta.Shasum != "" && !strings.EqualFold(c.meta.Shasum, actual) {
+		return c.fail("checksum %s doesn't match %s published by the registry", actual, c.meta.Shasum)
+	}
+	if len(c.meta.Hashes) > 0 {
+		if err := matchAnyHash(archive, c.meta.Hashes); err != nil {
+			return c.fail("package doesn't match any hash published by the network mirror: %s", err.Error())
+		}
+		verified = true
+	}
+	if len(c.lockedHashes) > 0 {
+		if err := matchAnyHash(archive, c.lockedHashes); err != nil {
+			return c.fail("package doesn't match any hash in the dependency lock file: %s", err.Error())
+		}
+		verified = true
+	}
+	if verified {
+		return nil
+	}
+	if !strings.HasPrefix(c.meta.DownloadUrl, "file:") {
+		return c.fail("no signed checksum or trusted hash is available to verify it")
+	}
+	log.Printf("Warning: executing unverified provider %s %s from %s, lock it in the dependency lock file to verify it", c.addr, c.version, c.meta.DownloadUrl)
+	return nil
+}
+
+func (c packageCheck) fail(format string, args ...any) error {
+	return fmt.Errorf("refuse to execute provider %s %s (%s): %s", c.addr, c.version, c.meta.Filename, fmt.Sprintf(format, args...))
+}
+
+// signedShasum downloads the SHA256SUMS document, verifies its signature with the registry's signing keys and returns
+// the checksum it lists for the package.
+func (c packageCheck) signedShasum() (string, error) {
+	if c.meta.ShasumsSignatureUrl == "" {
+		return "", fmt.Errorf("the registry publishes no signature for SHA256SUMS")
+	}
+	shasums, err := download(c.meta.ShasumsUrl)
+	if err != nil {
+		return "", err
+	}
+	signature, err := download(c.meta.ShasumsSignatureUrl)
+	if err != nil {
+		return "", err
+	}
+	if err = verifySignature(shasums, signature, c.meta.SigningKeys.GpgPublicKeys); err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(shasums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == c.meta.Filename {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("SHA256SUMS has no entry for %s", c.meta.Filename)
+}
+
+func verifySignature(signed, signature []byte, keys []gpgPublicKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("the registry publishes no signing key")
+	}
+	var keyring openpgp.EntityList
+	for _, key := range keys {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key.AsciiArmor))
+		if err != nil {
+			return fmt.Errorf("invalid signing key %s: %w", key.KeyId, err)
+		}
+		keyring = append(keyring, entities...)
+	}
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil); err != nil {
+		return fmt.Errorf("SHA256SUMS signature is invalid: %w", err)
+	}
+	return nil
+}
+
+// matchAnyHash checks archive against Terraform package hashes, `zh:` is the SHA256 of the zip archive
+// and `h1:` the dirhash of its content. Unsupported schemes are ignored.
+func matchAnyHash(archive []byte, hashes []string) error {
+	zh, h1, err := packageHashes(archive)
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if h == zh || h == h1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("got %s and %s", zh, h1)
+}
+
+func packageHashes(archive []byte) (string, string, error) {
+	sum := sha256.Sum256(archive)
+	zh := "zh:" + hex.EncodeToString(sum[:])
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return "", "", fmt.Errorf("provider package is not a valid zip archive: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	var names []string
+	for _, f := range r.File {
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	h1, err := dirhash.Hash1(names, func(name string) (io.ReadCloser, error) {
+		return files[name].Open()
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return zh, h1, nil
+}
+
+func download(u string) ([]byte, error) {
+	resp, err := registryHttpClient.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s, status code: %d", u, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
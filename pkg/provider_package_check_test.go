@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPackageFilename = "terraform-provider-internalcloud_1.0.0_linux_amd64.zip"
+
+func newTestPackage(t *testing.T, binary string) []byte {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	f, err := w.Create("terraform-provider-internalcloud_v1.0.0")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(binary))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) string {
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+// newTestSignedPackageMeta serves a SHA256SUMS for archive signed by signer, and returns package metadata publishing
+// the public key of publishedKey.
+func newTestSignedPackageMeta(t *testing.T, archive []byte, signer, publishedKey *openpgp.Entity) *packageMeta {
+	shasums := []byte(fmt.Sprintf("%s  terraform-provider-internalcloud_1.0.0_darwin_arm64.zip\n%s  %s\n", sha256Hex([]byte("other")), sha256Hex(archive), testPackageFilename))
+	signature := new(bytes.Buffer)
+	require.NoError(t, openpgp.DetachSign(signature, signer, bytes.NewReader(shasums), nil))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(shasums)
+	})
+	mux.HandleFunc("/SHA256SUMS.sig", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(signature.Bytes())
+	})
+	ts := httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+	previous := registryHttpClient
+	registryHttpClient = ts.Client()
+	t.Cleanup(func() {
+		registryHttpClient = previous
+	})
+	return &packageMeta{
+		Filename:            testPackageFilename,
+		DownloadUrl:         ts.URL + "/" + testPackageFilename,
+		ShasumsUrl:          ts.URL + "/SHA256SUMS",
+		ShasumsSignatureUrl: ts.URL + "/SHA256SUMS.sig",
+		SigningKeys: signingKeys{
+			GpgPublicKeys: []gpgPublicKey{
+				{
+					KeyId:      publishedKey.PrimaryKey.KeyIdString(),
+					AsciiArmor: armoredPublicKey(t, publishedKey),
+				},
+			},
+		},
+	}
+}
+
+func newTestSigningKey(t *testing.T) *openpgp.Entity {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	require.NoError(t, err)
+	return entity
+}
+
+func TestPackageCheck_SignedChecksum(t *testing.T) {
+	archive := newTestPackage(t, "binary")
+	key := newTestSigningKey(t)
+	check := packageCheck{
+		addr:    internalCloud,
+		version: "1.0.0",
+		meta:    newTestSignedPackageMeta(t, archive, key, key),
+	}
+	assert.NoError(t, check.verify(archive))
+
+	tampered := newTestPackage(t, "malicious")
+	err := check.verify(tampered)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), internalCloud.String())
+	assert.Contains(t, err.Error(), testPackageFilename)
+	assert.Contains(t, err.Error(), sha256Hex(archive))
+	assert.Contains(t, err.Error(), sha256Hex(tampered))
+}
+
+func TestPackageCheck_RefuseSignatureFromUnpublishedKey(t *testing.T) {
+	archive := newTestPackage(t, "binary")
+	check := packageCheck{
+		addr:    internalCloud,
+		version: "1.0.0",
+		meta:    newTestSignedPackageMeta(t, archive, newTestSigningKey(t), newTestSigningKey(t)),
+	}
+	err := check.verify(archive)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signature")
+}
+
+func TestPackageCheck_RegistryShasumMismatch(t *testing.T) {
+	archive := newTestPackage(t, "binary")
+	check := packageCheck{
+		addr:    internalCloud,
+		version: "1.0.0",
+		meta: &packageMeta{
+			Filename:    testPackageFilename,
+			DownloadUrl: "https://example.com/" + testPackageFilename,
+			Shasum:      sha256Hex([]byte("other")),
+		},
+	}
+	err := check.verify(archive)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't match")
+}
+
+func TestPackageCheck_RefuseRegistryShasumOnly(t *testing.T) {
+	archive := newTestPackage(t, "binary")
+	check := packageCheck{
+		addr:    internalCloud,
+		version: "1.0.0",
+		meta: &packageMeta{
+			Filename:    testPackageFilename,
+			DownloadUrl: "https://example.com/" + testPackageFilename,
+			Shasum:      sha256Hex(archive),
+		},
+	}
+	assert.Error(t, check.verify(archive))
+	check.lockedHashes = []string{"zh:" + sha256Hex(archive)}
+	assert.NoError(t, check.verify(archive))
+}
+
+func TestPackageCheck_RefuseRemotePackageWithoutChecksum(t *testing.T) {
+	archive := newTestPackage(t, "binary")
+	check := packageCheck{
+		addr:    internalCloud,
+		version: "1.0.0",
+		meta: &packageMeta{
+			Filename:    testPackageFilename,
+			DownloadUrl: "https://example.com/" + testPackageFilename,
+		},
+	}
+	assert.Error(t, check.verify(archive))
+	check.meta.DownloadUrl = "file:///mirror/" + testPackageFilename
+	assert.NoError(t, check.verify(archive))
+}
+
+func TestPackageCheck_Hashes(t *testing.T) {
+	archive := newTestPackage(t, "binary")
+	zh, h1, err := packageHashes(archive)
+	require.NoError(t, err)
+	other := newTestPackage(t, "other")
+	otherZh, otherH1, err := packageHashes(other)
+	require.NoError(t, err)
+	cases := []struct {
+		desc    string
+		mirror  []string
+		locked  []string
+		wantErr bool
+	}{
+		{
+			desc:   "mirror zh",
+			mirror: []string{otherH1, zh},
+		},
+		{
+			desc:   "locked h1",
+			locked: []string{h1, otherZh},
+		},
+		{
+			desc:    "mirror mismatch",
+			mirror:  []string{otherZh},
+			wantErr: true,
+		},
+		{
+			desc:    "lock mismatch",
+			mirror:  []string{zh},
+			locked:  []string{otherH1},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			check := packageCheck{
+				addr:    internalCloud,
+				version: "1.0.0",
+				meta: &packageMeta{
+					Filename:    testPackageFilename,
+					DownloadUrl: "https://mirror.example.com/" + testPackageFilename,
+					Hashes:      c.mirror,
+				},
+				lockedHashes: c.locked,
+			}
+			err := check.verify(archive)
+			if c.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProviderRouter_RefuseLocalPackageNotMatchingLockFile(t *testing.T) {
+	mirror := newTestFilesystemMirror(t)
+	router := newProviderRouter(http.DefaultTransport)
+	router.route(internalCloud, mirror, &lockedProvider{
+		Source:  internalCloud.String(),
+		Version: "1.1.0",
+		Hashes:  []string{"zh:" + sha256Hex([]byte("other"))},
+	})
+	meta, err := router.packageMeta(router.routes[routeKey("acme", "internalcloud")], "1.1.0", "linux", "amd64")
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, meta.DownloadUrl, nil)
+	require.NoError(t, err)
+	_, err = router.RoundTrip(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency lock file")
+}
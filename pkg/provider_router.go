@@ -0,0 +1,259 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/matt-FFFFFF/tfpluginschema"
+)
+
+// providerSource is somewhere provider packages can be installed from.
+type providerSource interface {
+	availableVersions(addr providerAddress) ([]string, error)
+	packageMeta(addr providerAddress, version, goos, goarch string) (*packageMeta, error)
+}
+
+// providerRouter is the transport of http.DefaultClient while tfpluginschema runs, tfpluginschema has no option to
+// use another client. tfpluginschema only speaks to the public registries, so registry API requests for routed
+// providers are answered from the providerSource registered for them instead. Package downloads handed out that way
+// are verified before they reach tfpluginschema, and `file://` package urls handed out by filesystem mirrors are read
+// from disk; every other request goes to the wrapped transport untouched.
+type providerRouter struct {
+	base   http.RoundTripper
+	mu     sync.RWMutex
+	routes map[string]providerRoute
+	// packages are the download urls handed out so far, no other local file is ever served.
+	packages map[string]packageCheck
+}
+
+type providerRoute struct {
+	addr   providerAddress
+	source providerSource
+	// locked is the provider's entry in the dependency lock file, nil if it isn't locked.
+	locked *lockedProvider
+}
+
+var (
+	router     *providerRouter
+	routerOnce sync.Once
+	// routerInstall counts the tfpluginschema calls in flight and remembers the transport of http.DefaultClient the
+	// router replaces while there is any.
+	routerInstall struct {
+		sync.Mutex
+		calls    int
+		previous http.RoundTripper
+	}
+)
+
+func getProviderRouter() *providerRouter {
+	routerOnce.Do(func() {
+		router = newProviderRouter(http.DefaultTransport)
+	})
+	return router
+}
+
+// installProviderRouter makes the router the transport of http.DefaultClient until the returned func is called, and
+// wraps the transport it replaces. Other code in the process using http.DefaultClient meanwhile goes through the
+// router too, which only affects requests to the public registries for routed providers and `file://` urls.
+func installProviderRouter() (restore func()) {
+	routerInstall.Lock()
+	defer routerInstall.Unlock()
+	r := getProviderRouter()
+	if routerInstall.calls == 0 {
+		routerInstall.previous = http.DefaultClient.Transport
+		base := routerInstall.previous
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		r.mu.Lock()
+		r.base = base
+		r.mu.Unlock()
+		http.DefaultClient.Transport = r
+	}
+	routerInstall.calls++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			routerInstall.Lock()
+			defer routerInstall.Unlock()
+			routerInstall.calls--
+			if routerInstall.calls == 0 {
+				http.DefaultClient.Transport = routerInstall.previous
+			}
+		})
+	}
+}
+
+func newProviderRouter(base http.RoundTripper) *providerRouter {
+	return &providerRouter{
+		base:     base,
+		routes:   make(map[string]providerRoute),
+		packages: make(map[string]packageCheck),
+	}
+}
+
+// route makes tfpluginschema requests for addr's namespace and type go to source, packages are also checked against
+// locked when it's not nil.
+// tfpluginschema identifies providers by namespace and type only, so the last route registered for them wins.
+func (p *providerRouter) route(addr providerAddress, source providerSource, locked *lockedProvider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.routes[routeKey(addr.namespace, addr.providerType)] = providerRoute{
+		addr:   addr,
+		source: source,
+		locked: locked,
+	}
+}
+
+func (p *providerRouter) transport() http.RoundTripper {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.base
+}
+
+func routeKey(namespace, providerType string) string {
+	return strings.ToLower(fmt.Sprintf("%s/%s", namespace, providerType))
+}
+
+func (p *providerRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.mu.RLock()
+	check, isPackage := p.packages[req.URL.String()]
+	p.mu.RUnlock()
+	if isPackage {
+		return p.downloadPackage(req, check)
+	}
+	if req.URL.Scheme == "file" {
+		return nil, fmt.Errorf("refuse to read unknown local file %s", req.URL)
+	}
+	segments, ok := schemaServerRequestSegments(req)
+	if !ok || len(segments) < 3 {
+		return p.transport().RoundTrip(req)
+	}
+	p.mu.RLock()
+	route, ok := p.routes[routeKey(segments[0], segments[1])]
+	p.mu.RUnlock()
+	if !ok {
+		return p.transport().RoundTrip(req)
+	}
+	var body any
+	var err error
+	switch {
+	case len(segments) == 3 && segments[2] == "versions":
+		body, err = p.versions(route)
+	case len(segments) == 6 && segments[3] == "download":
+		body, err = p.packageMeta(route, segments[2], segments[4], segments[5])
+	default:
+		return nil, fmt.Errorf("unsupported registry request %s for provider %s", req.URL, route.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return jsonResponse(req, body)
+}
+
+func (p *providerRouter) versions(route providerRoute) (any, error) {
+	versions, err := route.source.availableVersions(route.addr)
+	if err != nil {
+		return nil, err
+	}
+	type version struct {
+		Version string `json:"version"`
+	}
+	resp := struct {
+		Versions []version `json:"versions"`
+	}{}
+	for _, v := range versions {
+		resp.Versions = append(resp.Versions, version{Version: v})
+	}
+	return resp, nil
+}
+
+func (p *providerRouter) packageMeta(route providerRoute, version, goos, goarch string) (*packageMeta, error) {
+	meta, err := route.source.packageMeta(route.addr, version, goos, goarch)
+	if err != nil {
+		return nil, err
+	}
+	check := packageCheck{
+		addr:    route.addr,
+		version: version,
+		meta:    meta,
+	}
+	if route.locked != nil && route.locked.Version == version {
+		check.lockedHashes = route.locked.Hashes
+	}
+	p.mu.Lock()
+	p.packages[meta.DownloadUrl] = check
+	p.mu.Unlock()
+	return meta, nil
+}
+
+// downloadPackage reads the whole package and only returns it when it passes check.
+func (p *providerRouter) downloadPackage(req *http.Request, check packageCheck) (*http.Response, error) {
+	var content []byte
+	var err error
+	if req.URL.Scheme == "file" {
+		content, err = readLocalPackage(localFilePath(req.URL))
+	} else {
+		content, err = p.fetch(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = check.verify(content); err != nil {
+		return nil, err
+	}
+	return newResponse(req, "application/zip", content), nil
+}
+
+func (p *providerRouter) fetch(req *http.Request) ([]byte, error) {
+	resp, err := p.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s, status code: %d", req.URL, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// schemaServerRequestSegments returns the path segments after `/v1/providers/` if req is a tfpluginschema registry request.
+func schemaServerRequestSegments(req *http.Request) ([]string, bool) {
+	for _, registryType := range []tfpluginschema.RegistryType{tfpluginschema.RegistryTypeOpenTofu, tfpluginschema.RegistryTypeTerraform} {
+		prefix := registryType.BaseURL() + "/"
+		u := req.URL.String()
+		if strings.HasPrefix(u, prefix) {
+			return strings.Split(strings.TrimPrefix(u, prefix), "/"), true
+		}
+	}
+	return nil, false
+}
+
+func jsonResponse(req *http.Request, body any) (*http.Response, error) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(req, "application/json", content), nil
+}
+
+func newResponse(req *http.Request, contentType string, content []byte) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          io.NopCloser(bytes.NewReader(content)),
+		ContentLength: int64(len(content)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	goversion "github.com/hashicorp/go-version"
+)
+
+const serviceDiscoveryPath = "/.well-known/terraform.json"
+
+// registryHttpClient is used for every registry API call made by newres itself. It must not be http.DefaultClient,
+// whose transport is the providerRouter while tfpluginschema runs.
+var registryHttpClient = &http.Client{}
+
+var registryClients sync.Map
+
+var _ providerSource = &registryClient{}
+
+// registryClient talks to the provider registry protocol of one registry host, located via service discovery.
+type registryClient struct {
+	hostname     string
+	providersUrl *url.URL
+	token        string
+}
+
+// packageMeta is the provider registry protocol's `download` response, describing one provider package.
+type packageMeta struct {
+	Protocols           []string    `json:"protocols,omitempty"`
+	OS                  string      `json:"os"`
+	Arch                string      `json:"arch"`
+	Filename            string      `json:"filename"`
+	DownloadUrl         string      `json:"download_url"`
+	ShasumsUrl          string      `json:"shasums_url,omitempty"`
+	ShasumsSignatureUrl string      `json:"shasums_signature_url,omitempty"`
+	Shasum              string      `json:"shasum,omitempty"`
+	SigningKeys         signingKeys `json:"signing_keys"`
+	// Hashes are the `zh:` or `h1:` package hashes published by a network mirror.
+	Hashes []string `json:"-"`
+}
+
+type signingKeys struct {
+	GpgPublicKeys []gpgPublicKey `json:"gpg_public_keys"`
+}
+
+type gpgPublicKey struct {
+	KeyId      string `json:"key_id"`
+	AsciiArmor string `json:"ascii_armor"`
+}
+
+// newRegistryClient returns the client for hostname, running service discovery and credentials lookup on first use.
+func newRegistryClient(hostname string) (*registryClient, error) {
+	if c, ok := registryClients.Load(hostname); ok {
+		return c.(*registryClient), nil
+	}
+	cliCfg, err := loadCliConfig()
+	if err != nil {
+		return nil, err
+	}
+	c := &registryClient{
+		hostname: hostname,
+		token:    cliCfg.token(hostname),
+	}
+	if c.providersUrl, err = c.discoverProvidersService(); err != nil {
+		return nil, err
+	}
+	registryClients.Store(hostname, c)
+	return c, nil
+}
+
+// discoverProvidersService asks the host which URL serves the `providers.v1` service,
+// see https://developer.hashicorp.com/terraform/internals/remote-service-discovery.
+func (c *registryClient) discoverProvidersService() (*url.URL, error) {
+	discoveryUrl := &url.URL{Scheme: "https", Host: c.hostname, Path: serviceDiscoveryPath}
+	var services map[string]any
+	if err := c.getJson(discoveryUrl.String(), &services); err != nil {
+		return nil, fmt.Errorf("service discovery failed for %s: %w", c.hostname, err)
+	}
+	service, ok := services["providers.v1"].(string)
+	if !ok {
+		return nil, fmt.Errorf("host %s does not provide a provider registry", c.hostname)
+	}
+	serviceUrl, err := url.Parse(service)
+	if err != nil {
+		return nil, fmt.Errorf("invalid providers.v1 service url %q on %s: %w", service, c.hostname, err)
+	}
+	serviceUrl = discoveryUrl.ResolveReference(serviceUrl)
+	if !strings.HasSuffix(serviceUrl.Path, "/") {
+		serviceUrl.Path += "/"
+	}
+	return serviceUrl, nil
+}
+
+func (c *registryClient) availableVersions(addr providerAddress) ([]string, error) {
+	var resp struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	if err := c.getJson(c.providerUrl(addr, "versions"), &resp); err != nil {
+		return nil, fmt.Errorf("failed to list versions of provider %s: %w", addr, err)
+	}
+	var versions []string
+	for _, v := range resp.Versions {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+func (c *registryClient) packageMeta(addr providerAddress, version, goos, goarch string) (*packageMeta, error) {
+	downloadUrl := c.providerUrl(addr, version, "download", goos, goarch)
+	var meta packageMeta
+	if err := c.getJson(downloadUrl, &meta); err != nil {
+		return nil, fmt.Errorf("failed to get download info of provider %s %s for %s_%s: %w", addr, version, goos, goarch, err)
+	}
+	// URLs in registry responses may be relative to the request.
+	base, _ := url.Parse(downloadUrl)
+	for _, u := range []*string{&meta.DownloadUrl, &meta.ShasumsUrl, &meta.ShasumsSignatureUrl} {
+		if *u == "" {
+			continue
+		}
+		ref, err := url.Parse(*u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url %q in download info of provider %s: %w", *u, addr, err)
+		}
+		*u = base.ResolveReference(ref).String()
+	}
+	return &meta, nil
+}
+
+func (c *registryClient) providerUrl(addr providerAddress, segments ...string) string {
+	path := append([]string{addr.namespace, addr.providerType}, segments...)
+	return c.providersUrl.ResolveReference(&url.URL{Path: strings.Join(path, "/")}).String()
+}
+
+func (c *registryClient) getJson(u string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := registryHttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// latestStableVersion returns the highest version which is not a pre-release.
+func latestStableVersion(versions []string) (string, error) {
+	return latestMatchingVersion(versions, nil)
+}
+
+// latestMatchingVersion returns the highest version which is not a pre-release and satisfies constraints.
+func latestMatchingVersion(versions []string, constraints goversion.Constraints) (string, error) {
+	var latest *goversion.Version
+	for _, v := range versions {
+		ver, err := goversion.NewVersion(v)
+		if err != nil || ver.Prerelease() != "" || !constraints.Check(ver) {
+			continue
+		}
+		if latest == nil || ver.GreaterThan(latest) {
+			latest = ver
+		}
+	}
+	if latest == nil && len(constraints) > 0 {
+		return "", fmt.Errorf("no stable version matching %q found", constraints.String())
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no stable version found")
+	}
+	return latest.Original(), nil
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// registryDocsUrl is the Terraform Registry's API root serving provider documentation. Like the provider search it's
+// not part of the registry protocol, so other registries don't have it.
+var registryDocsUrl = "https://" + defaultRegistryHost
+
+type registryProviderDoc struct {
+	Id       string `json:"id"`
+	Slug     string `json:"slug"`
+	Category string `json:"category"`
+	Language string `json:"language"`
+}
+
+// documentedProvider returns the provider and the exact version resourceType's schema comes from, which is the version
+// its documentation must match.
+func documentedProvider(resourceType string, cfg Config) (providerAddress, string, error) {
+	addr, err := resolveProviderAddress(resourceType, cfg)
+	if err != nil {
+		return providerAddress{}, "", err
+	}
+	if version, ok := exactVersion(cfg.ProviderVersion); ok {
+		return addr, version, nil
+	}
+	cliCfg, err := loadCliConfig()
+	if err != nil {
+		return providerAddress{}, "", err
+	}
+	source, err := cliCfg.providerSource(addr)
+	if err != nil {
+		return providerAddress{}, "", err
+	}
+	version, err := providerVersion(source, addr, cfg)
+	if err != nil {
+		return providerAddress{}, "", err
+	}
+	return addr, version, nil
+}
+
+// providerDocument returns the markdown documentation of resourceType in version of the provider from the Terraform
+// Registry.
+func providerDocument(addr providerAddress, version, resourceType string) (string, error) {
+	if addr.hostname != defaultRegistryHost {
+		return "", fmt.Errorf("%s doesn't serve provider documentation", addr.hostname)
+	}
+	return registryDocument(addr, version, resourceType)
+}
+
+// registryDocument looks resourceType up in the documentation index of one provider version and fetches its page.
+func registryDocument(addr providerAddress, version, resourceType string) (string, error) {
+	client := &registryClient{hostname: defaultRegistryHost}
+	var provider struct {
+		Docs []registryProviderDoc `json:"docs"`
+	}
+	if err := client.getJson(fmt.Sprintf("%s/v1/providers/%s/%s/%s", registryDocsUrl, addr.namespace, addr.providerType, version), &provider); err != nil {
+		return "", fmt.Errorf("failed to list documentation of %s %s: %w", addr, version, err)
+	}
+	id := ""
+	for _, d := range provider.Docs {
+		if d.Category != "resources" || (d.Language != "" && d.Language != "hcl") {
+			continue
+		}
+		if d.Slug == resourceTypeWithoutVendor(resourceType) || d.Slug == resourceType {
+			id = d.Id
+			break
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("no documentation for %s in %s %s", resourceType, addr, version)
+	}
+	var doc struct {
+		Data struct {
+			Attributes struct {
+				Content string `json:"content"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := client.getJson(fmt.Sprintf("%s/v2/provider-docs/%s", registryDocsUrl, id), &doc); err != nil {
+		return "", fmt.Errorf("failed to get documentation of %s: %w", resourceType, err)
+	}
+	return strings.ReplaceAll(doc.Data.Attributes.Content, "\r\n", "\n"), nil
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRegistryDocs serves the documentation of hashicorp/helm 2.12.0 and returns the requested paths.
+func newTestRegistryDocs(t *testing.T) *[]string {
+	var paths []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/providers/hashicorp/helm/2.12.0":
+			_, _ = io.WriteString(w, `{"docs":[
+{"id":"1","slug":"release","category":"data-sources","language":"hcl"},
+{"id":"2","slug":"release","category":"resources","language":"cdktf"},
+{"id":"3","slug":"release","category":"resources","language":"hcl"}]}`)
+		case "/v2/provider-docs/3":
+			_, _ = io.WriteString(w, `{"data":{"attributes":{"content":"## Argument Reference\r\n\r\n* `+"`name`"+` - (Required) Release name.\r\n\r\n## Attributes Reference\r\n"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	previousClient, previousUrl := registryHttpClient, registryDocsUrl
+	registryHttpClient, registryDocsUrl = ts.Client(), ts.URL
+	t.Cleanup(func() {
+		registryHttpClient, registryDocsUrl = previousClient, previousUrl
+	})
+	return &paths
+}
+
+func TestProviderDocument_PinnedVersion(t *testing.T) {
+	paths := newTestRegistryDocs(t)
+	addr, version, err := documentedProvider("helm_release", Config{ProviderNamespace: "hashicorp", ProviderVersion: "v2.12.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "2.12.0", version)
+	markdown, err := providerDocument(addr, version, "helm_release")
+	require.NoError(t, err)
+	assert.Equal(t, "## Argument Reference\n\n* `name` - (Required) Release name.\n\n## Attributes Reference\n", markdown)
+	assert.Equal(t, []string{"/v1/providers/hashicorp/helm/2.12.0", "/v2/provider-docs/3"}, *paths)
+
+	doc, err := newDocument("helm_release", Config{ProviderNamespace: "hashicorp", ProviderVersion: "2.12.0"}).parseDocument()
+	require.NoError(t, err)
+	assert.Equal(t, "(Required) Release name.", doc["name"].desc)
+}
+
+func TestProviderDocument_NotDocumented(t *testing.T) {
+	newTestRegistryDocs(t)
+	addr, version, err := documentedProvider("helm_chart", Config{ProviderNamespace: "hashicorp", ProviderVersion: "2.12.0"})
+	require.NoError(t, err)
+	_, err = providerDocument(addr, version, "helm_chart")
+	assert.ErrorContains(t, err, "no documentation for helm_chart")
+
+	markdown, err := content("helm_chart", Config{ProviderNamespace: "hashicorp", ProviderVersion: "2.12.0"})
+	require.NoError(t, err)
+	assert.Empty(t, markdown)
+}
+
+func TestProviderDocument_OtherRegistry(t *testing.T) {
+	paths := newTestRegistryDocs(t)
+	addr, version, err := documentedProvider("internalcloud_vm", Config{ProviderSource: "app.terraform.io/acme/internalcloud", ProviderVersion: "1.0.0"})
+	require.NoError(t, err)
+	_, err = providerDocument(addr, version, "internalcloud_vm")
+	assert.ErrorContains(t, err, "app.terraform.io doesn't serve provider documentation")
+	assert.Empty(t, *paths)
+}
+
+func TestContent_GitHubFallbackAtVersionTag(t *testing.T) {
+	newTestRegistryDocs(t)
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/v2.13.0/release.md" {
+			_, _ = io.WriteString(w, "release docs at v2.13.0")
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(ts.Close)
+	urlTemplates["helm"] = ts.URL + "/%s/%s.md"
+	t.Cleanup(func() {
+		delete(urlTemplates, "helm")
+	})
+
+	markdown, err := content("helm_release", Config{ProviderNamespace: "hashicorp", ProviderVersion: "2.13.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "release docs at v2.13.0", markdown)
+
+	markdown, err = content("helm_release", Config{ProviderNamespace: "hashicorp", ProviderVersion: "2.14.0"})
+	assert.Error(t, err)
+	assert.Empty(t, markdown)
+	assert.Equal(t, []string{"/v2.13.0/release.md", "/v2.14.0/release.md"}, paths, "documents of another version must not be used")
+}
@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRegistry starts a registry serving the acme/internalcloud provider under /api/providers/,
+// requiring token as bearer token when it's not empty.
+func newTestRegistry(t *testing.T, token string) (*httptest.Server, providerAddress) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(serviceDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"providers.v1": "/api/providers"}`)
+	})
+	mux.HandleFunc("/api/providers/acme/internalcloud/", func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/versions"):
+			_, _ = io.WriteString(w, `{"versions":[{"version":"1.2.0"},{"version":"1.10.0"},{"version":"2.0.0-beta1"}]}`)
+		case strings.HasSuffix(r.URL.Path, "/1.10.0/download/linux/amd64"):
+			_, _ = io.WriteString(w, `{"os":"linux","arch":"amd64","filename":"terraform-provider-internalcloud_1.10.0_linux_amd64.zip","download_url":"../../../../files/terraform-provider-internalcloud_1.10.0_linux_amd64.zip","shasum":"abc"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ts := httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+	previous := registryHttpClient
+	registryHttpClient = ts.Client()
+	t.Cleanup(func() {
+		registryHttpClient = previous
+	})
+	return ts, providerAddress{
+		hostname:     strings.TrimPrefix(ts.URL, "https://"),
+		namespace:    "acme",
+		providerType: "internalcloud",
+	}
+}
+
+func TestRegistryClient_LatestVersionSkipsPrerelease(t *testing.T) {
+	setTestCliConfig(t, "")
+	_, addr := newTestRegistry(t, "")
+	client, err := newRegistryClient(addr.hostname)
+	require.NoError(t, err)
+	version, err := providerVersion(client, addr, Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.0", version)
+}
+
+func TestProviderVersion_ResolveConstraint(t *testing.T) {
+	setTestCliConfig(t, "")
+	_, addr := newTestRegistry(t, "")
+	client, err := newRegistryClient(addr.hostname)
+	require.NoError(t, err)
+	version, err := providerVersion(client, addr, Config{ProviderVersion: "~> 1.2.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", version)
+	version, err = providerVersion(client, addr, Config{ProviderVersion: "v1.3.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.0", version, "an exact version is used as it is")
+	_, err = providerVersion(client, addr, Config{ProviderVersion: ">= 3.0"})
+	assert.ErrorContains(t, err, "no stable version matching")
+
+	lockFile := filepath.Join(t.TempDir(), ".terraform.lock.hcl")
+	require.NoError(t, os.WriteFile(lockFile, []byte(fmt.Sprintf(`provider %q {
+  version = "1.2.0"
+}
+`, addr.String())), 0600))
+	version, err = providerVersion(client, addr, Config{ProviderVersion: ">= 1.0", DependencyLockFile: lockFile})
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", version, "the locked version is preferred when it satisfies the constraint")
+	version, err = providerVersion(client, addr, Config{ProviderVersion: ">= 1.5", DependencyLockFile: lockFile})
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.0", version)
+}
+
+func TestRegistryClient_UseCredentials(t *testing.T) {
+	setTestCliConfig(t, "")
+	_, addr := newTestRegistry(t, "secret")
+	t.Setenv(credentialsEnvName(addr.hostname), "secret")
+	client, err := newRegistryClient(addr.hostname)
+	require.NoError(t, err)
+	versions, err := client.availableVersions(addr)
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+}
+
+func TestRegistryClient_PackageMetaResolvesRelativeUrl(t *testing.T) {
+	setTestCliConfig(t, "")
+	ts, addr := newTestRegistry(t, "")
+	client, err := newRegistryClient(addr.hostname)
+	require.NoError(t, err)
+	meta, err := client.packageMeta(addr, "1.10.0", "linux", "amd64")
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/api/providers/acme/files/terraform-provider-internalcloud_1.10.0_linux_amd64.zip", meta.DownloadUrl)
+}
+
+func TestProviderRouter_AnswersSchemaServerRequestsFromRoutedSource(t *testing.T) {
+	setTestCliConfig(t, "")
+	_, addr := newTestRegistry(t, "")
+	client, err := newRegistryClient(addr.hostname)
+	require.NoError(t, err)
+	router := newProviderRouter(http.DefaultTransport)
+	router.route(addr, client, nil)
+
+	req, err := http.NewRequest(http.MethodGet, "https://registry.opentofu.org/v1/providers/acme/internalcloud/versions", nil)
+	require.NoError(t, err)
+	resp, err := router.RoundTrip(req)
+	require.NoError(t, err)
+	var versions struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&versions))
+	assert.Len(t, versions.Versions, 3)
+
+	req, err = http.NewRequest(http.MethodGet, fmt.Sprintf("https://registry.opentofu.org/v1/providers/acme/internalcloud/%s/download/linux/amd64", "1.10.0"), nil)
+	require.NoError(t, err)
+	resp, err = router.RoundTrip(req)
+	require.NoError(t, err)
+	var meta packageMeta
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&meta))
+	assert.Equal(t, "terraform-provider-internalcloud_1.10.0_linux_amd64.zip", meta.Filename)
+}
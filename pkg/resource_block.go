@@ -76,7 +76,7 @@ func (r *resourceBlock) variableName() string {
 
 func (r *resourceBlock) init() {
 	r.attrs, r.nbs = normalizeBlockContents(r)
-	r.writeBlock = hclwrite.NewBlock("resource", []string{r.name, "this"})
+	r.writeBlock = hclwrite.NewBlock("resource", []string{r.name, r.cfg.GetResourceName()})
 }
 
 func (r *resourceBlock) schemaAttributeToHCLBlock(attributeName string, attribute *tfjson.SchemaAttribute, descriptions map[string]argumentDescription) *hclwrite.Block {
@@ -90,7 +90,7 @@ func (r *resourceBlock) schemaAttributeToHCLBlock(attributeName string, attribut
 		SpacesBefore: 0,
 	}})
 
-	if description, ok := descriptions[attributeName]; ok {
+	if description, ok := describe(descriptions, attributeName); ok {
 		wb.Body().SetAttributeValue("description", cty.StringVal(description.desc))
 	}
 	if attribute.Sensitive {
@@ -99,7 +99,9 @@ func (r *resourceBlock) schemaAttributeToHCLBlock(attributeName string, attribut
 	if attribute.Required {
 		wb.Body().SetAttributeValue("nullable", cty.False)
 	}
-	if attribute.Optional {
+	if v, ok := documentedDefault(r.defaults(descriptions), attributeName, attribute.AttributeType); ok && attribute.Optional {
+		wb.Body().SetAttributeValue("default", v)
+	} else if attribute.Optional {
 		wb.Body().SetAttributeRaw("default", newTokens().
 			ident("null", 0).Tokens)
 	}
@@ -108,10 +110,47 @@ func (r *resourceBlock) schemaAttributeToHCLBlock(attributeName string, attribut
 	if attribute.Description != "" {
 		wb.Body().SetAttributeValue("description", cty.StringVal(attribute.Description))
 	}
+	if attribute.AttributeNestedType != nil {
+		wb.Body().SetAttributeRaw("description", r.nestedAttributeDescriptionTokens(attributeName, attribute, descriptions))
+	}
+	for _, v := range attributeValidations(r, newAttribute(r, attributeName, attribute), r.variableRef, descriptions) {
+		v.appendTo(wb)
+	}
 
 	return wb
 }
 
+// nestedAttributeDescriptionTokens returns the description of the variable of an attribute with nested attributes, its
+// own description followed by theirs. Like theirs, its own description comes from the document, the schema's otherwise.
+func (r *resourceBlock) nestedAttributeDescriptionTokens(attributeName string, attribute *tfjson.SchemaAttribute, descriptions map[string]argumentDescription) hclwrite.Tokens {
+	desc := attribute.Description
+	if d, ok := describe(descriptions, attributeName); ok && d.desc != "" {
+		desc = d.desc
+	}
+	t := newTokens().
+		oHeredoc(fmt.Sprintf("<<-%s", r.cfg.GetDelimiter())).
+		newLine()
+	if desc != "" {
+		t.ident(desc, 2).newLine().newLine()
+	}
+	return t.rawTokens(nestedAttributeDescription(attributeName, attribute.AttributeNestedType, descriptions).Tokens).
+		cHeredoc(r.cfg.GetDelimiter()).
+		newLine().Tokens
+}
+
+// variableRef returns the variable of the argument name in MultipleVariables mode.
+func (r *resourceBlock) variableRef(name string) string {
+	return fmt.Sprintf("var.%s", composeName(r.variablePrefix, name))
+}
+
+// defaults returns the descriptions whose documented defaults become variable defaults, none with Config.NullDefaults.
+func (r *resourceBlock) defaults(descriptions map[string]argumentDescription) map[string]argumentDescription {
+	if r.cfg.NullDefaults {
+		return nil
+	}
+	return descriptions
+}
+
 func (r *resourceBlock) attributes() []*attribute {
 	return r.attrs
 }
@@ -138,6 +177,18 @@ func newNestedBlock(b block, name string, s *tfjson.SchemaBlockType) *nestedBloc
 	return nb
 }
 
+// newRestoredNestedBlock returns a nested block restored from an attribute of type t.
+func newRestoredNestedBlock(b block, name string, s *tfjson.SchemaBlockType, t cty.Type) *nestedBlock {
+	nb := &nestedBlock{
+		SchemaBlockType: s,
+		name:            name,
+		parent:          b,
+		restoredType:    t,
+	}
+	nb.attrs, nb.nbs = normalizeBlockContents(nb)
+	return nb
+}
+
 func (r *resourceBlock) generateResource(document map[string]argumentDescription, generateVariableBlock bool, attrExpr attrExpr, nbIterator nestedBlockIteratorExpr) (string, error) {
 	for _, attr := range r.attrs {
 		if attr.computedOnly() {
@@ -166,6 +217,11 @@ func (r *resourceBlock) generateResource(document map[string]argumentDescription
 			return "", err
 		}
 	}
+	if r.cfg.PreventDestroy {
+		r.appendNewline()
+		lifecycle := r.writeBlock.Body().AppendNewBlock("lifecycle", nil)
+		lifecycle.Body().SetAttributeValue("prevent_destroy", cty.True)
+	}
 	r.appendRootBlock(r.writeBlock)
 	return string(r.f.Bytes()), nil
 }
@@ -207,6 +263,7 @@ func (r *resourceBlock) blockDescriptionTokens(b block, documents map[string]arg
 		oHeredoc(fmt.Sprintf("<<-%s", r.cfg.GetDelimiter())).
 		newLine().
 		rawTokens(descriptionTokens).
+		rawTokens(forceNewWarningTokens(b, documents).Tokens).
 		cHeredoc(r.cfg.GetDelimiter()).
 		newLine().Tokens
 }
@@ -248,7 +305,7 @@ func (r *resourceBlock) generateMultiVarsResource(document map[string]argumentDe
 }
 
 func (r *resourceBlock) appendVariableBlock(b block, variableName string, document map[string]argumentDescription) error {
-	variableType := generateVariableType(b, true)
+	variableType := generateVariableType(b, true, r.defaults(document))
 	variableType = fmt.Sprintf("type = %s", variableType)
 	cfg, diag := hclwrite.ParseConfig([]byte(variableType), "", hcl.InitialPos)
 	if diag.HasErrors() {
@@ -259,12 +316,33 @@ func (r *resourceBlock) appendVariableBlock(b block, variableName string, docume
 	vb.Body().AppendUnstructuredTokens(cfg.BuildTokens(hclwrite.Tokens{}))
 	vb.Body().AppendNewline()
 
-	if b.minItems() == 0 {
+	nb, isNestedBlock := b.(*nestedBlock)
+	switch {
+	case isNestedBlock && nb.alwaysPresent():
+		if !requiresArguments(nb) {
+			vb.Body().SetAttributeValue("default", cty.EmptyObjectVal)
+		}
+		vb.Body().SetAttributeValue("nullable", cty.False)
+	case b.minItems() == 0:
 		vb.Body().SetAttributeValue("default", cty.NullVal(cty.String))
-	} else {
+	default:
 		vb.Body().SetAttributeValue("nullable", cty.False)
 	}
 
 	vb.Body().SetAttributeRaw("description", r.blockDescriptionTokens(b, document))
+
+	var validations []variableValidation
+	ref := fmt.Sprintf("var.%s", variableName)
+	if isNestedBlock {
+		// the block is a variable of its own, its constraints refer to the variables of the other arguments
+		validations = append(constraintValidations(r, nb.name, r.variableRef, document), nestedBlockValidations(nb, ref, document)...)
+	} else {
+		validations = argumentValidations(b, func(name string) string {
+			return fmt.Sprintf("%s.%s", ref, name)
+		}, document)
+	}
+	for _, v := range validations {
+		v.appendTo(vb)
+	}
 	return nil
 }
@@ -57,7 +57,7 @@ variable "vpc_cidr_block" {
 }
 
 func TestGenerateVariableBlockForRequiredNestedBlockShouldDeclareNullableAsFalse(t *testing.T) {
-	code, err := GenerateResource(NewResourceGenerateCommand("azurerm_kubernetes_cluster", Config{}, nil))
+	code, err := GenerateResource(NewResourceGenerateCommand("azurerm_kubernetes_cluster", Config{SchemaSource: testSchemaSource}, nil))
 	require.NoError(t, err)
 	config, diag := hclsyntax.ParseConfig([]byte(code), "main.tf", hcl.InitialPos)
 	require.False(t, diag.HasErrors())
@@ -77,7 +77,7 @@ func TestGenerateVariableBlockForRequiredNestedBlockShouldDeclareNullableAsFalse
 }
 
 func TestGenerateVariableBlockForOptionalNestedBlockShouldDeclareDefaultToNull(t *testing.T) {
-	code, err := GenerateResource(NewResourceGenerateCommand("azurerm_kubernetes_cluster", Config{}, nil))
+	code, err := GenerateResource(NewResourceGenerateCommand("azurerm_kubernetes_cluster", Config{SchemaSource: testSchemaSource}, nil))
 	require.NoError(t, err)
 	config, diag := hclsyntax.ParseConfig([]byte(code), "main.tf", hcl.InitialPos)
 	require.False(t, diag.HasErrors())
@@ -101,7 +101,7 @@ func TestGenerateVariableType_ComplexObject(t *testing.T) {
 	input := containerAppSchema.Block.NestedBlocks["template"]
 	r, err := newResourceBlock("azurerm_container_app", containerAppSchema, Config{})
 	require.NoError(t, err)
-	actual := strings.Replace(generateVariableType(newNestedBlock(r, "template", input), true), " ", "", -1)
+	actual := strings.Replace(generateVariableType(newNestedBlock(r, "template", input), true, nil), " ", "", -1)
 	expected := strings.Replace(strings.Replace(`object({
     max_replicas           = optional(number)
     min_replicas           = optional(number)
@@ -231,7 +231,7 @@ func TestGenerateVariableType_ListOfSimpleObject(t *testing.T) {
 		Block.NestedBlocks["env"]
 	r, err := newResourceBlock("azurerm_container_app", containerAppSchema, Config{})
 	require.NoError(t, err)
-	actual := strings.Replace(generateVariableType(newNestedBlock(r, "template", input), true), " ", "", -1)
+	actual := strings.Replace(generateVariableType(newNestedBlock(r, "template", input), true, nil), " ", "", -1)
 	expected := strings.Replace(strings.Replace(`list(object({
   name = string
   secret_name = optional(string)
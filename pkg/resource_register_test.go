@@ -34,8 +34,8 @@ import (
 	tls "github.com/lonegunmanb/terraform-tls-schema/v4/generated"
 )
 
-// resourceSchemas is a static schema registry used only by tests.
-// Production code uses dynamic schema retrieval via getResourceSchema().
+// resourceSchemas is a static schema registry used only by tests, served by NewMapSchemaSource.
+// Production code uses dynamic schema retrieval via NewTfPluginSchemaSource().
 var resourceSchemas = make(map[string]*tfjson.Schema, 0)
 
 func init() {
@@ -75,9 +75,3 @@ func init() {
 		mergeSchemas(resourceSchemas, schemas)
 	}
 }
-
-func mergeSchemas(s1, s2 map[string]*tfjson.Schema) {
-	for k, v := range s2 {
-		s1[k] = v
-	}
-}
@@ -1,12 +1,11 @@
 package pkg
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 	"sync"
 
+	goversion "github.com/hashicorp/go-version"
 	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/matt-FFFFFF/tfpluginschema"
 )
@@ -14,6 +13,9 @@ import (
 var (
 	schemaServer     *tfpluginschema.Server
 	schemaServerOnce sync.Once
+	// resolvedVersions remembers the exact version each provider version constraint resolved to, so the schema and the
+	// documentation of one run come from the same version.
+	resolvedVersions sync.Map
 )
 
 func getSchemaServer() *tfpluginschema.Server {
@@ -32,80 +34,100 @@ func CleanupSchemaServer() {
 }
 
 // getResourceSchema dynamically retrieves the Terraform resource schema
-// for the given resource type by downloading the provider binary via the
-// OpenTofu registry and querying it over gRPC.
-// If namespace is empty, it falls back to a default based on the provider type.
-// If version is empty, the latest version is fetched from the Terraform Registry.
-func getResourceSchema(resourceType string, namespace string, version string) (*tfjson.Schema, error) {
+// for the given resource type by downloading the provider binary and querying it over gRPC.
+// The provider comes from Config.ProviderSource when set; otherwise the namespace is Config.ProviderNamespace
+// or discovered from the Terraform Registry by provider type.
+// Providers on other registry hosts are located via service discovery and downloaded from there, and the
+// `provider_installation` mirrors of the Terraform CLI configuration are honoured when configured.
+// Config.ProviderVersion is resolved to an exact version by providerVersion.
+// Provider packages must match the registry's signed checksums, and the hashes of Config.DependencyLockFile
+// when it locks the same version, before they are executed.
+func getResourceSchema(resourceType string, cfg Config) (*tfjson.Schema, error) {
 	if !resourceTypeValid(resourceType) {
 		return nil, fmt.Errorf("invalid resource type: %s", resourceType)
 	}
-	providerType := resourceVendor(resourceType)
-	if namespace == "" {
-		namespace = defaultNamespace(providerType)
+	addr, err := resolveProviderAddress(resourceType, cfg)
+	if err != nil {
+		return nil, err
 	}
-	if version == "" {
-		var err error
-		version, err = getLatestProviderVersion(namespace, providerType)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get latest version for provider %s/%s: %w", namespace, providerType, err)
-		}
-	} else {
-		version = strings.TrimPrefix(version, "v")
+	cliCfg, err := loadCliConfig()
+	if err != nil {
+		return nil, err
+	}
+	source, err := cliCfg.providerSource(addr)
+	if err != nil {
+		return nil, err
+	}
+	version, err := providerVersion(source, addr, cfg)
+	if err != nil {
+		return nil, err
 	}
 
+	locked, err := loadLockedProvider(cfg.DependencyLockFile, addr)
+	if err != nil {
+		return nil, err
+	}
+	// Every provider goes through the router so its package is verified before tfpluginschema executes it.
+	getProviderRouter().route(addr, source, locked)
 	req := tfpluginschema.Request{
-		Namespace: namespace,
-		Name:      providerType,
+		Namespace: addr.namespace,
+		Name:      addr.providerType,
 		Version:   version,
 	}
 	server := getSchemaServer()
+	restore := installProviderRouter()
 	schema, err := server.GetResourceSchema(req, resourceType)
+	restore()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get resource schema for %s: %w", resourceType, err)
 	}
 	return schema, nil
 }
 
-func defaultNamespace(providerType string) string {
-	switch providerType {
-	case "azapi":
-		return "Azure"
-	case "msgraph":
-		return "microsoft"
-	case "alicloud":
-		return "aliyun"
-	default:
-		return "hashicorp"
+// providerVersion returns the exact version of addr newres uses for the Config.ProviderVersion constraint: an exact
+// version as it is, else the version Config.DependencyLockFile locks when it satisfies the constraint, else the latest
+// stable version of source that does.
+func providerVersion(source providerSource, addr providerAddress, cfg Config) (string, error) {
+	if version, ok := exactVersion(cfg.ProviderVersion); ok {
+		return version, nil
 	}
-}
-
-func getLatestProviderVersion(namespace, providerType string) (string, error) {
-	url := fmt.Sprintf("https://registry.terraform.io/v1/providers/%s/%s", namespace, providerType)
-
-	resp, err := http.Get(url) // #nosec G107
+	var constraints goversion.Constraints
+	if cfg.ProviderVersion != "" {
+		var err error
+		if constraints, err = goversion.NewConstraint(cfg.ProviderVersion); err != nil {
+			return "", fmt.Errorf("invalid provider version constraint %q: %w", cfg.ProviderVersion, err)
+		}
+	}
+	key := strings.Join([]string{addr.String(), cfg.ProviderVersion, cfg.DependencyLockFile}, "|")
+	if version, ok := resolvedVersions.Load(key); ok {
+		return version.(string), nil
+	}
+	locked, err := loadLockedProvider(cfg.DependencyLockFile, addr)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch provider info from registry: %w", err)
+		return "", err
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("registry API returned status %d for provider %s/%s", resp.StatusCode, namespace, providerType)
+	if locked != nil {
+		if v, err := goversion.NewVersion(locked.Version); err == nil && constraints.Check(v) {
+			resolvedVersions.Store(key, locked.Version)
+			return locked.Version, nil
+		}
 	}
-
-	var providerInfo struct {
-		Tag string `json:"tag"`
+	versions, err := source.availableVersions(addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to get versions of provider %s: %w", addr, err)
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&providerInfo); err != nil {
-		return "", fmt.Errorf("failed to decode provider info response: %w", err)
+	version, err := latestMatchingVersion(versions, constraints)
+	if err != nil {
+		return "", fmt.Errorf("%w for provider %s", err, addr)
 	}
+	resolvedVersions.Store(key, version)
+	return version, nil
+}
 
-	if providerInfo.Tag == "" {
-		return "", fmt.Errorf("no tag found in provider info for %s/%s", namespace, providerType)
+// exactVersion returns the version constraint names, when it's a single version like "4.39.0" or "v4.39.0".
+func exactVersion(constraint string) (string, bool) {
+	if _, err := goversion.NewVersion(constraint); err != nil {
+		return "", false
 	}
-
-	return strings.TrimPrefix(providerInfo.Tag, "v"), nil
+	return strings.TrimPrefix(constraint, "v"), true
 }
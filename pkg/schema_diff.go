@@ -0,0 +1,181 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SchemaChangeKind string
+
+const (
+	SchemaChangeAdded          SchemaChangeKind = "added"
+	SchemaChangeRemoved        SchemaChangeKind = "removed"
+	SchemaChangeBecameRequired SchemaChangeKind = "became_required"
+	SchemaChangeBecameOptional SchemaChangeKind = "became_optional"
+	SchemaChangeDeprecated     SchemaChangeKind = "deprecated"
+	SchemaChangeTypeChanged    SchemaChangeKind = "type_changed"
+)
+
+const (
+	schemaElementAttribute = "attribute"
+	schemaElementBlock     = "block"
+)
+
+// SchemaChange is one difference between two versions of a resource schema. Path is the dot separated address of the
+// argument or nested block within the resource, e.g. `default_node_pool.vm_size`.
+type SchemaChange struct {
+	Path    string           `json:"path"`
+	Element string           `json:"element"`
+	Kind    SchemaChangeKind `json:"kind"`
+	From    string           `json:"from,omitempty"`
+	To      string           `json:"to,omitempty"`
+}
+
+type SchemaDiff struct {
+	ResourceType string         `json:"resource_type"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Changes      []SchemaChange `json:"changes"`
+}
+
+// DiffResourceSchema compares the schema of resourceType in provider versions from and to. Both schemas come from
+// Config.SchemaSource and are walked as the same attribute and nested block tree resources are generated from.
+func DiffResourceSchema(resourceType string, cfg Config, from, to string) (*SchemaDiff, error) {
+	fromBlock, err := versionedResourceBlock(resourceType, cfg, from)
+	if err != nil {
+		return nil, err
+	}
+	toBlock, err := versionedResourceBlock(resourceType, cfg, to)
+	if err != nil {
+		return nil, err
+	}
+	d := &SchemaDiff{
+		ResourceType: resourceType,
+		From:         from,
+		To:           to,
+		Changes:      []SchemaChange{},
+	}
+	d.diffBlock("", fromBlock, toBlock)
+	return d, nil
+}
+
+func versionedResourceBlock(resourceType string, cfg Config, version string) (*resourceBlock, error) {
+	cfg.ProviderVersion = version
+	schema, err := cfg.GetSchemaSource().ResourceSchema(resourceType, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema for %s %s: %w", resourceType, version, err)
+	}
+	return newResourceBlock(resourceType, schema, cfg)
+}
+
+func (d *SchemaDiff) diffBlock(prefix string, from, to block) {
+	fromAttrs := make(map[string]*attribute)
+	for _, a := range from.attributes() {
+		fromAttrs[a.name] = a
+	}
+	toAttrs := make(map[string]*attribute)
+	for _, a := range to.attributes() {
+		toAttrs[a.name] = a
+		old, ok := fromAttrs[a.name]
+		if !ok {
+			d.add(prefix+a.name, schemaElementAttribute, SchemaChangeAdded, "", "")
+			continue
+		}
+		d.diffAttribute(prefix+a.name, old, a)
+	}
+	for _, a := range from.attributes() {
+		if _, ok := toAttrs[a.name]; !ok {
+			d.add(prefix+a.name, schemaElementAttribute, SchemaChangeRemoved, "", "")
+		}
+	}
+
+	fromBlocks := make(map[string]*nestedBlock)
+	for _, nb := range from.nestedBlocks() {
+		fromBlocks[nb.name] = nb
+	}
+	toBlocks := make(map[string]*nestedBlock)
+	for _, nb := range to.nestedBlocks() {
+		toBlocks[nb.name] = nb
+		old, ok := fromBlocks[nb.name]
+		if !ok {
+			d.add(prefix+nb.name, schemaElementBlock, SchemaChangeAdded, "", "")
+			continue
+		}
+		d.diffNestedBlock(prefix+nb.name, old, nb)
+	}
+	for _, nb := range from.nestedBlocks() {
+		if _, ok := toBlocks[nb.name]; !ok {
+			d.add(prefix+nb.name, schemaElementBlock, SchemaChangeRemoved, "", "")
+		}
+	}
+}
+
+func (d *SchemaDiff) diffAttribute(path string, from, to *attribute) {
+	if fromType, toType := ctyTypeToVariableTypeString(from.AttributeType), ctyTypeToVariableTypeString(to.AttributeType); fromType != toType {
+		d.add(path, schemaElementAttribute, SchemaChangeTypeChanged, fromType, toType)
+	}
+	if !from.Required && to.Required {
+		d.add(path, schemaElementAttribute, SchemaChangeBecameRequired, "", "")
+	}
+	if from.Required && !to.Required {
+		d.add(path, schemaElementAttribute, SchemaChangeBecameOptional, "", "")
+	}
+	if !from.Deprecated && to.Deprecated {
+		d.add(path, schemaElementAttribute, SchemaChangeDeprecated, "", "")
+	}
+}
+
+func (d *SchemaDiff) diffNestedBlock(path string, from, to *nestedBlock) {
+	if fromMode, toMode := nestingModeDescription(from), nestingModeDescription(to); fromMode != toMode {
+		d.add(path, schemaElementBlock, SchemaChangeTypeChanged, fromMode, toMode)
+	}
+	if from.minItems() == 0 && to.minItems() > 0 {
+		d.add(path, schemaElementBlock, SchemaChangeBecameRequired, "", "")
+	}
+	if from.minItems() > 0 && to.minItems() == 0 {
+		d.add(path, schemaElementBlock, SchemaChangeBecameOptional, "", "")
+	}
+	if !from.schemaBlock().Deprecated && to.schemaBlock().Deprecated {
+		d.add(path, schemaElementBlock, SchemaChangeDeprecated, "", "")
+	}
+	d.diffBlock(path+".", from, to)
+}
+
+func nestingModeDescription(nb *nestedBlock) string {
+	if nb.maxItems() > 0 {
+		return fmt.Sprintf("%s (max %d)", nb.NestingMode(), nb.maxItems())
+	}
+	return string(nb.NestingMode())
+}
+
+func (d *SchemaDiff) add(path, element string, kind SchemaChangeKind, from, to string) {
+	d.Changes = append(d.Changes, SchemaChange{
+		Path:    path,
+		Element: element,
+		Kind:    kind,
+		From:    from,
+		To:      to,
+	})
+}
+
+// Text renders the diff for humans, one change per line: `+` for additions, `-` for removals and `~` for changes.
+func (d *SchemaDiff) Text() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s -> %s\n", d.ResourceType, d.From, d.To))
+	if len(d.Changes) == 0 {
+		sb.WriteString("  no changes\n")
+	}
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case SchemaChangeAdded:
+			sb.WriteString(fmt.Sprintf("+ %s %s\n", c.Element, c.Path))
+		case SchemaChangeRemoved:
+			sb.WriteString(fmt.Sprintf("- %s %s\n", c.Element, c.Path))
+		case SchemaChangeTypeChanged:
+			sb.WriteString(fmt.Sprintf("~ %s %s type changed from %s to %s\n", c.Element, c.Path, c.From, c.To))
+		default:
+			sb.WriteString(fmt.Sprintf("~ %s %s %s\n", c.Element, c.Path, strings.ReplaceAll(string(c.Kind), "_", " ")))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"fmt"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// versionedSchemaSource serves one schema per provider version.
+type versionedSchemaSource map[string]*tfjson.Schema
+
+func (v versionedSchemaSource) ResourceSchema(resourceType string, cfg Config) (*tfjson.Schema, error) {
+	schema, ok := v[cfg.ProviderVersion]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrSchemaNotFound, resourceType, cfg.ProviderVersion)
+	}
+	return schema, nil
+}
+
+func TestDiffResourceSchema(t *testing.T) {
+	source := versionedSchemaSource{
+		"1.0.0": {
+			Block: &tfjson.SchemaBlock{
+				Attributes: map[string]*tfjson.SchemaAttribute{
+					"name":     {AttributeType: cty.String, Required: true},
+					"size":     {AttributeType: cty.String, Optional: true},
+					"tags":     {AttributeType: cty.Map(cty.String), Optional: true},
+					"old_flag": {AttributeType: cty.Bool, Optional: true},
+				},
+				NestedBlocks: map[string]*tfjson.SchemaBlockType{
+					"network": {
+						NestingMode: tfjson.SchemaNestingModeList,
+						MaxItems:    1,
+						Block: &tfjson.SchemaBlock{
+							Attributes: map[string]*tfjson.SchemaAttribute{
+								"subnet_id": {AttributeType: cty.String, Optional: true},
+							},
+						},
+					},
+					"legacy": {
+						NestingMode: tfjson.SchemaNestingModeList,
+						Block:       &tfjson.SchemaBlock{},
+					},
+				},
+			},
+		},
+		"2.0.0": {
+			Block: &tfjson.SchemaBlock{
+				Attributes: map[string]*tfjson.SchemaAttribute{
+					"name": {AttributeType: cty.String, Required: true},
+					"size": {AttributeType: cty.String, Required: true},
+					"tags": {AttributeType: cty.Map(cty.String), Optional: true, Deprecated: true},
+					"sku":  {AttributeType: cty.Number, Optional: true},
+				},
+				NestedBlocks: map[string]*tfjson.SchemaBlockType{
+					"network": {
+						NestingMode: tfjson.SchemaNestingModeList,
+						MaxItems:    1,
+						MinItems:    1,
+						Block: &tfjson.SchemaBlock{
+							Attributes: map[string]*tfjson.SchemaAttribute{
+								"subnet_id": {AttributeType: cty.List(cty.String), Optional: true},
+								"dns":       {AttributeType: cty.String, Optional: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	diff, err := DiffResourceSchema("fake_resource", Config{SchemaSource: source}, "1.0.0", "2.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []SchemaChange{
+		{Path: "size", Element: "attribute", Kind: SchemaChangeBecameRequired},
+		{Path: "sku", Element: "attribute", Kind: SchemaChangeAdded},
+		{Path: "tags", Element: "attribute", Kind: SchemaChangeDeprecated},
+		{Path: "old_flag", Element: "attribute", Kind: SchemaChangeRemoved},
+		{Path: "network", Element: "block", Kind: SchemaChangeBecameRequired},
+		{Path: "network.dns", Element: "attribute", Kind: SchemaChangeAdded},
+		{Path: "network.subnet_id", Element: "attribute", Kind: SchemaChangeTypeChanged, From: "string", To: "list(string)"},
+		{Path: "legacy", Element: "block", Kind: SchemaChangeRemoved},
+	}, diff.Changes)
+	assert.Contains(t, diff.Text(), "~ attribute network.subnet_id type changed from string to list(string)\n")
+	assert.Contains(t, diff.Text(), "- block legacy\n")
+}
+
+func TestDiffResourceSchema_NoChanges(t *testing.T) {
+	schema := &tfjson.Schema{Block: &tfjson.SchemaBlock{
+		Attributes: map[string]*tfjson.SchemaAttribute{
+			"name": {AttributeType: cty.String, Required: true},
+		},
+	}}
+	diff, err := DiffResourceSchema("fake_resource", Config{SchemaSource: versionedSchemaSource{"1.0.0": schema, "1.1.0": schema}}, "1.0.0", "1.1.0")
+	require.NoError(t, err)
+	assert.Empty(t, diff.Changes)
+	assert.Equal(t, "fake_resource 1.0.0 -> 1.1.0\n  no changes\n", diff.Text())
+}
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// ErrSchemaNotFound is returned by a SchemaSource that doesn't know the requested resource type.
+var ErrSchemaNotFound = errors.New("resource schema not found")
+
+// SchemaSource provides the schemas resources are generated from. Set Config.SchemaSource to generate from something
+// other than the provider binaries downloaded from the registry, e.g. to run offline.
+type SchemaSource interface {
+	ResourceSchema(resourceType string, cfg Config) (*tfjson.Schema, error)
+}
+
+// DocumentSource provides the documentation of resources. A SchemaSource implementing it documents the resources it
+// has schemas for, other SchemaSources generate resources without documentation unless Config.DocsDir is set.
+type DocumentSource interface {
+	ResourceDocument(resourceType string, cfg Config) (string, error)
+}
+
+var _ SchemaSource = tfPluginSchemaSource{}
+var _ DocumentSource = tfPluginSchemaSource{}
+var _ SchemaSource = mapSchemaSource{}
+var _ SchemaSource = chainSchemaSource{}
+var _ DocumentSource = chainSchemaSource{}
+
+type tfPluginSchemaSource struct{}
+
+// NewTfPluginSchemaSource returns the default SchemaSource, which downloads the provider and reads the schema from it
+// with tfpluginschema.
+func NewTfPluginSchemaSource() SchemaSource {
+	return tfPluginSchemaSource{}
+}
+
+func (tfPluginSchemaSource) ResourceSchema(resourceType string, cfg Config) (*tfjson.Schema, error) {
+	return getResourceSchema(resourceType, cfg)
+}
+
+// ResourceDocument returns the document of resourceType for the provider version the schema comes from, from the
+// Terraform Registry or GitHub.
+func (tfPluginSchemaSource) ResourceDocument(resourceType string, cfg Config) (string, error) {
+	return registryContent(resourceType, cfg)
+}
+
+type mapSchemaSource struct {
+	schemas map[string]*tfjson.Schema
+}
+
+// NewMapSchemaSource returns a SchemaSource serving schemas by resource type, like the `Resources` maps of the
+// lonegunmanb/terraform-*-schema modules.
+func NewMapSchemaSource(schemas map[string]*tfjson.Schema) SchemaSource {
+	return mapSchemaSource{
+		schemas: schemas,
+	}
+}
+
+func (m mapSchemaSource) ResourceSchema(resourceType string, _ Config) (*tfjson.Schema, error) {
+	schema, ok := m.schemas[resourceType]
+	if !ok || schema == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, resourceType)
+	}
+	return schema, nil
+}
+
+// NewJsonFileSchemaSource returns a SchemaSource serving the resource schemas in a file written by
+// `terraform providers schema -json`. When several providers in the file have the resource type, the one with the
+// lowest provider address wins.
+func NewJsonFileSchemaSource(path string) (SchemaSource, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file %s: %w", path, err)
+	}
+	var providerSchemas tfjson.ProviderSchemas
+	if err = json.Unmarshal(content, &providerSchemas); err != nil {
+		return nil, fmt.Errorf("failed to decode schema file %s: %w", path, err)
+	}
+	var providers []string
+	for p := range providerSchemas.Schemas {
+		providers = append(providers, p)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(providers)))
+	schemas := make(map[string]*tfjson.Schema)
+	for _, p := range providers {
+		mergeSchemas(schemas, providerSchemas.Schemas[p].ResourceSchemas)
+	}
+	return NewMapSchemaSource(schemas), nil
+}
+
+func mergeSchemas(s1, s2 map[string]*tfjson.Schema) {
+	for k, v := range s2 {
+		s1[k] = v
+	}
+}
+
+type chainSchemaSource struct {
+	sources []SchemaSource
+}
+
+// NewChainSchemaSource returns a SchemaSource trying sources in order, falling back to the next one on error.
+func NewChainSchemaSource(sources ...SchemaSource) SchemaSource {
+	return chainSchemaSource{
+		sources: sources,
+	}
+}
+
+func (c chainSchemaSource) ResourceSchema(resourceType string, cfg Config) (*tfjson.Schema, error) {
+	var errs []error
+	for _, s := range c.sources {
+		schema, err := s.ResourceSchema(resourceType, cfg)
+		if err == nil {
+			return schema, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, resourceType)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ResourceDocument returns the document of the first source serving both the schema and the documentation of
+// resourceType.
+func (c chainSchemaSource) ResourceDocument(resourceType string, cfg Config) (string, error) {
+	for _, s := range c.sources {
+		documents, ok := s.(DocumentSource)
+		if !ok {
+			continue
+		}
+		if _, err := s.ResourceSchema(resourceType, cfg); err != nil {
+			continue
+		}
+		return documents.ResourceDocument(resourceType, cfg)
+	}
+	return "", nil
+}
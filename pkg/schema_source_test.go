@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const testProviderSchemasJson = `{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/null": {
+      "resource_schemas": {
+        "null_resource": {
+          "version": 0,
+          "block": {
+            "attributes": {
+              "triggers": {"type": ["map", "string"], "optional": true}
+            }
+          }
+        }
+      }
+    },
+    "registry.terraform.io/acme/null": {
+      "resource_schemas": {
+        "null_resource": {
+          "version": 1,
+          "block": {}
+        }
+      }
+    }
+  }
+}`
+
+func testSchema(attributeName string) *tfjson.Schema {
+	return &tfjson.Schema{
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				attributeName: {
+					AttributeType: cty.String,
+					Optional:      true,
+				},
+			},
+		},
+	}
+}
+
+func TestJsonFileSchemaSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(testProviderSchemasJson), 0600))
+	source, err := NewJsonFileSchemaSource(path)
+	require.NoError(t, err)
+	schema, err := source.ResourceSchema("null_resource", Config{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), schema.Version)
+	_, err = source.ResourceSchema("null_data_source", Config{})
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestChainSchemaSource_FallBackToNextSource(t *testing.T) {
+	source := NewChainSchemaSource(
+		NewMapSchemaSource(map[string]*tfjson.Schema{"fake_a": testSchema("a")}),
+		NewMapSchemaSource(map[string]*tfjson.Schema{"fake_a": testSchema("b"), "fake_b": testSchema("b")}),
+	)
+	schema, err := source.ResourceSchema("fake_a", Config{})
+	require.NoError(t, err)
+	assert.Contains(t, schema.Block.Attributes, "a")
+	schema, err = source.ResourceSchema("fake_b", Config{})
+	require.NoError(t, err)
+	assert.Contains(t, schema.Block.Attributes, "b")
+	_, err = source.ResourceSchema("fake_c", Config{})
+	assert.ErrorIs(t, err, ErrSchemaNotFound)
+}
+
+func TestGenerateResource_UseSchemaSourceFromConfig(t *testing.T) {
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource: NewMapSchemaSource(map[string]*tfjson.Schema{"fake_resource": testSchema("display_name")}),
+	}, nil))
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(generated, `variable "resource_display_name"`))
+}
+
+type documentedSchemaSource struct {
+	SchemaSource
+	document string
+}
+
+func (d documentedSchemaSource) ResourceDocument(string, Config) (string, error) {
+	return d.document, nil
+}
+
+func TestChainSchemaSource_DocumentOfSourceServingSchema(t *testing.T) {
+	source := NewChainSchemaSource(
+		NewMapSchemaSource(map[string]*tfjson.Schema{"fake_a": testSchema("a")}),
+		documentedSchemaSource{
+			SchemaSource: NewMapSchemaSource(map[string]*tfjson.Schema{"fake_b": testSchema("b")}),
+			document:     "document of fake_b",
+		},
+	)
+	document, err := content("fake_a", Config{SchemaSource: source})
+	require.NoError(t, err)
+	assert.Empty(t, document, "the source of fake_a has no documents and mustn't fall back to the registry")
+	document, err = content("fake_b", Config{SchemaSource: source})
+	require.NoError(t, err)
+	assert.Equal(t, "document of fake_b", document)
+}
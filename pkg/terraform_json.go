@@ -0,0 +1,518 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// jsonObject is a JSON object that keeps its members in insertion order, so the JSON output reads like the HCL it's
+// converted from.
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newJsonObject() *jsonObject {
+	return &jsonObject{values: make(map[string]any)}
+}
+
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// remove removes key and its value.
+func (o *jsonObject) remove(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	o.keys = slices.DeleteFunc(o.keys, func(k string) bool {
+		return k == key
+	})
+}
+
+// child returns the object under key, adding an empty one if there isn't any.
+func (o *jsonObject) child(key string) *jsonObject {
+	if c, ok := o.values[key].(*jsonObject); ok {
+		return c
+	}
+	c := newJsonObject()
+	o.set(key, c)
+	return c
+}
+
+// addBlock adds a block body under key, repeated blocks become an array.
+func (o *jsonObject) addBlock(key string, body *jsonObject) {
+	switch existing := o.values[key].(type) {
+	case nil:
+		o.set(key, body)
+	case []any:
+		o.set(key, append(existing, body))
+	default:
+		o.set(key, []any{existing, body})
+	}
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// ToTerraformJson converts HCL configuration, e.g. generated by GenerateResource, to the Terraform JSON syntax.
+// Expressions become `${...}` templates, literals stay JSON values.
+func ToTerraformJson(src []byte) ([]byte, error) {
+	file, diag := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	root := newJsonObject()
+	for _, b := range file.Body.(*hclsyntax.Body).Blocks {
+		parent := root
+		key := b.Type
+		for _, l := range b.Labels {
+			parent = parent.child(key)
+			key = l
+		}
+		body, err := jsonBody(b.Body, src, b.Type)
+		if err != nil {
+			return nil, err
+		}
+		if len(b.Labels) == 0 {
+			parent.addBlock(key, body)
+			continue
+		}
+		parent.set(key, body)
+	}
+	content, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// jsonBody converts a block body, blockType is the type of the block it belongs to.
+func jsonBody(body *hclsyntax.Body, src []byte, blockType string) (*jsonObject, error) {
+	type item struct {
+		start int
+		attr  *hclsyntax.Attribute
+		block *hclsyntax.Block
+	}
+	var items []item
+	for _, attr := range body.Attributes {
+		items = append(items, item{start: attr.SrcRange.Start.Byte, attr: attr})
+	}
+	for _, b := range body.Blocks {
+		items = append(items, item{start: b.TypeRange.Start.Byte, block: b})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].start < items[j].start
+	})
+	obj := newJsonObject()
+	for _, i := range items {
+		if i.attr != nil {
+			value, err := jsonExpression(i.attr.Expr, src, blockType, i.attr.Name)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(i.attr.Name, value)
+			continue
+		}
+		nested, err := jsonBody(i.block.Body, src, i.block.Type)
+		if err != nil {
+			return nil, err
+		}
+		if i.block.Type == "dynamic" && len(i.block.Labels) == 1 {
+			obj.child("dynamic").addBlock(i.block.Labels[0], nested)
+			continue
+		}
+		obj.addBlock(i.block.Type, nested)
+	}
+	return obj, nil
+}
+
+// jsonExpression converts an expression to its Terraform JSON value.
+func jsonExpression(expr hclsyntax.Expression, src []byte, blockType, name string) (any, error) {
+	source := strings.TrimSpace(string(expr.Range().SliceBytes(src)))
+	if blockType == "variable" && name == "type" {
+		// type constraints are keywords, in JSON they're the same expression as a string
+		return source, nil
+	}
+	if len(expr.Variables()) == 0 {
+		if v, diag := expr.Value(nil); !diag.HasErrors() && v.IsWhollyKnown() {
+			// Terraform reads a variable's own arguments verbatim, other strings are templates
+			return jsonValue(v, blockType != "variable")
+		}
+	}
+	if wrap, ok := expr.(*hclsyntax.TemplateWrapExpr); ok {
+		return fmt.Sprintf("${%s}", strings.TrimSpace(string(wrap.Wrapped.Range().SliceBytes(src)))), nil
+	}
+	if tmpl, ok := expr.(*hclsyntax.TemplateExpr); ok && strings.HasPrefix(source, `"`) && !tmpl.IsStringLiteral() {
+		return strings.TrimSuffix(strings.TrimPrefix(source, `"`), `"`), nil
+	}
+	if strings.HasPrefix(source, "<<") {
+		// a heredoc must end with a newline before the closing brace
+		return fmt.Sprintf("${%s\n}", source), nil
+	}
+	return fmt.Sprintf("${%s}", source), nil
+}
+
+// jsonValue converts a known cty value to a JSON value, strings are escaped when they'd be read as templates.
+func jsonValue(v cty.Value, template bool) (any, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		s := v.AsString()
+		if template {
+			s = strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+		}
+		return s, nil
+	case t == cty.Number:
+		return json.Number(v.AsBigFloat().Text('f', -1)), nil
+	case t == cty.Bool:
+		return v.True(), nil
+	case t.IsListType() || t.IsSetType() || t.IsTupleType():
+		items := make([]any, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, e := it.Element()
+			item, err := jsonValue(e, template)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case t.IsMapType() || t.IsObjectType():
+		obj := newJsonObject()
+		for it := v.ElementIterator(); it.Next(); {
+			k, e := it.Element()
+			item, err := jsonValue(e, template)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(k.AsString(), item)
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %s", t.FriendlyName())
+}
+
+// SplitGeneratedJson splits generated code into files like SplitGeneratedCode, in the Terraform JSON syntax, e.g.
+// `variables.tf.json` and `main.tf.json`.
+func SplitGeneratedJson(generated string, layout FileLayout) ([]GeneratedFile, error) {
+	files, err := SplitGeneratedCode(generated, layout)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		content, err := ToTerraformJson(f.Content)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = GeneratedFile{
+			Name:    f.Name + ".json",
+			Content: content,
+		}
+	}
+	return files, nil
+}
+
+// WriteJsonModule writes generated code into the module in dir as Terraform JSON files, merged into the existing
+// `*.tf.json` files like MergeIntoModule does with HCL: new blocks go to the file layout puts them in, existing
+// variables, in any JSON file, get their type and description refreshed. An existing block that differs from the
+// generated one is a conflict, reported as *ConflictError without changing any file unless force is set, in which case
+// it's replaced.
+func WriteJsonModule(dir, generated string, layout FileLayout, force bool) error {
+	generatedFiles, err := SplitGeneratedJson(generated, layout)
+	if err != nil {
+		return err
+	}
+	files, err := readTfJsonFiles(dir)
+	if err != nil {
+		return err
+	}
+	var targets []*tfJsonFile
+	var conflicts []string
+	for _, f := range generatedFiles {
+		content, err := parseJsonObject(f.Content)
+		if err != nil {
+			return fmt.Errorf("error parsing generated %s: %w", f.Name, err)
+		}
+		target := jsonModuleFile(dir, files, f.Name)
+		targets = append(targets, target)
+		for _, b := range jsonBlocks(content) {
+			if len(b.labels) == 0 {
+				// blocks like locals may appear many times, only an identical one is the same block
+				if !hasIdenticalJsonBlock(files, b) {
+					target.root.addBlock(b.blockType, b.body)
+					target.changed = true
+				}
+				continue
+			}
+			parent, existingFile := findJsonBlock(files, b.blockType, b.labels...)
+			label := b.labels[len(b.labels)-1]
+			if parent == nil {
+				parent = target.root.child(b.blockType)
+				for _, l := range b.labels[:len(b.labels)-1] {
+					parent = parent.child(l)
+				}
+				parent.set(label, b.body)
+				target.changed = true
+				continue
+			}
+			existing, _ := parent.values[label].(*jsonObject)
+			if b.blockType == "variable" && existing != nil {
+				existingFile.changed = mergeJsonVariable(existing, b.body) || existingFile.changed
+				continue
+			}
+			if sameJson(parent.values[label], b.body) {
+				continue
+			}
+			if !force {
+				conflicts = append(conflicts, fmt.Sprintf("%s in %s", jsonBlockHeader(b), filepath.Base(existingFile.path)))
+				continue
+			}
+			parent.set(label, b.body)
+			existingFile.changed = true
+		}
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return writeChangedJsonFiles(append(files, targets...))
+}
+
+// mergeJsonVariable refreshes existing with the generated type, description and validations, and reports whether it
+// changed.
+func mergeJsonVariable(existing, generated *jsonObject) bool {
+	changed := false
+	for _, name := range mergedVariableAttributes {
+		value, ok := generated.values[name]
+		if !ok || sameJson(existing.values[name], value) {
+			continue
+		}
+		existing.set(name, value)
+		changed = true
+	}
+	return mergeJsonValidations(existing, generated) || changed
+}
+
+// mergeJsonValidations replaces the validations newres generated in existing with the generated ones, like
+// mergeValidations, and reports whether they changed. Validations written by hand are kept.
+func mergeJsonValidations(existing, generated *jsonObject) bool {
+	var kept, previous []any
+	for _, v := range jsonArray(existing.values["validation"]) {
+		if o, ok := v.(*jsonObject); ok {
+			if message, ok := o.values["error_message"].(string); ok && generatedValidationMessage(message) {
+				previous = append(previous, v)
+				continue
+			}
+		}
+		kept = append(kept, v)
+	}
+	current := jsonArray(generated.values["validation"])
+	if sameJson(previous, current) {
+		return false
+	}
+	validations := append(kept, current...)
+	switch len(validations) {
+	case 0:
+		existing.remove("validation")
+	case 1:
+		existing.set("validation", validations[0])
+	default:
+		existing.set("validation", validations)
+	}
+	return true
+}
+
+// jsonArray returns the blocks under a key of a Terraform JSON object, a single block isn't wrapped in an array.
+func jsonArray(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+// jsonBlockLabels are the numbers of labels of the labelled block types in Terraform JSON.
+var jsonBlockLabels = map[string]int{
+	"resource": 2,
+	"data":     2,
+	"variable": 1,
+	"output":   1,
+	"module":   1,
+	"provider": 1,
+}
+
+// jsonBlock is a block of a Terraform JSON file, body is the object holding its arguments.
+type jsonBlock struct {
+	blockType string
+	labels    []string
+	body      *jsonObject
+}
+
+// jsonBlocks returns the blocks of a Terraform JSON file, in the order they're written.
+func jsonBlocks(root *jsonObject) []jsonBlock {
+	var blocks []jsonBlock
+	for _, blockType := range root.keys {
+		var walk func(value any, labels []string)
+		walk = func(value any, labels []string) {
+			switch v := value.(type) {
+			case []any:
+				for _, item := range v {
+					walk(item, labels)
+				}
+			case *jsonObject:
+				if len(labels) == jsonBlockLabels[blockType] {
+					blocks = append(blocks, jsonBlock{blockType: blockType, labels: labels, body: v})
+					return
+				}
+				for _, k := range v.keys {
+					walk(v.values[k], append(append([]string{}, labels...), k))
+				}
+			}
+		}
+		walk(root.values[blockType], nil)
+	}
+	return blocks
+}
+
+func jsonBlockHeader(b jsonBlock) string {
+	header := []string{b.blockType}
+	for _, l := range b.labels {
+		header = append(header, fmt.Sprintf("%q", l))
+	}
+	return strings.Join(header, " ")
+}
+
+// sameJson reports whether two JSON values are equal, regardless of the order of object members.
+func sameJson(a, b any) bool {
+	var values [2]any
+	for i, v := range []any{a, b} {
+		content, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if err = json.Unmarshal(content, &values[i]); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(values[0], values[1])
+}
+
+// parseJsonObject parses a JSON object, keeping the order of its members.
+func parseJsonObject(content []byte) (*jsonObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	value, err := decodeJsonValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := value.(*jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return obj, nil
+}
+
+func decodeJsonValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		obj := newJsonObject()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), value)
+		}
+		_, err = decoder.Token()
+		return obj, err
+	case json.Delim('['):
+		items := make([]any, 0)
+		for decoder.More() {
+			item, err := decodeJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err = decoder.Token()
+		return items, err
+	}
+	return token, nil
+}
+
+// jsonReferencedVariables returns the names of the variables the templates in a Terraform JSON value reference.
+func jsonReferencedVariables(value any) []string {
+	var names []string
+	switch v := value.(type) {
+	case string:
+		expr, diag := hclsyntax.ParseTemplate([]byte(v), "", hcl.InitialPos)
+		if diag.HasErrors() {
+			return nil
+		}
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() != "var" || len(traversal) < 2 {
+				continue
+			}
+			if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+				names = append(names, attr.Name)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			names = append(names, jsonReferencedVariables(item)...)
+		}
+	case *jsonObject:
+		for _, k := range v.keys {
+			names = append(names, jsonReferencedVariables(v.values[k])...)
+		}
+	}
+	return names
+}
@@ -0,0 +1,179 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToTerraformJson_GeneratedResource(t *testing.T) {
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+	}, nil))
+	require.NoError(t, err)
+	files, err := SplitGeneratedJson(generated, DefaultLayout)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "variables.tf.json", files[0].Name)
+	assert.Equal(t, "main.tf.json", files[1].Name)
+	for _, f := range files {
+		_, diag := hcljson.Parse(f.Content, f.Name)
+		require.False(t, diag.HasErrors(), diag.Error())
+	}
+
+	var main struct {
+		Resource map[string]map[string]map[string]any `json:"resource"`
+	}
+	require.NoError(t, json.Unmarshal(files[1].Content, &main))
+	resource := main.Resource["fake_resource"]["this"]
+	assert.Equal(t, "${var.resource_name}", resource["name"])
+	identity := resource["dynamic"].(map[string]any)["identity"].(map[string]any)
+	assert.Equal(t, "${var.resource_identity == null ? [] : [var.resource_identity]}", identity["for_each"])
+	assert.Equal(t, map[string]any{"type": "${identity.value.type}"}, identity["content"])
+
+	var variables struct {
+		Variable map[string]map[string]any `json:"variable"`
+	}
+	require.NoError(t, json.Unmarshal(files[0].Content, &variables))
+	assert.Equal(t, "string", variables.Variable["resource_name"]["type"])
+	assert.Equal(t, false, variables.Variable["resource_name"]["nullable"])
+	assert.Contains(t, variables.Variable["resource_sku"], "default")
+	assert.Nil(t, variables.Variable["resource_sku"]["default"])
+}
+
+func TestToTerraformJson_Expressions(t *testing.T) {
+	content, err := ToTerraformJson([]byte(`resource "fake_resource" "this" {
+  name     = "prefix-${var.name}"
+  literal  = "keep $${this}"
+  count    = 2
+  tags     = { env = "dev" }
+  id       = <<EOT
+${var.id}
+EOT
+  zone     = upper(var.zone)
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+`))
+	require.NoError(t, err)
+	_, diag := hcljson.Parse(content, "main.tf.json")
+	require.False(t, diag.HasErrors(), diag.Error())
+	var resource struct {
+		Resource map[string]map[string]map[string]any `json:"resource"`
+	}
+	require.NoError(t, json.Unmarshal(content, &resource))
+	this := resource.Resource["fake_resource"]["this"]
+	assert.Equal(t, "prefix-${var.name}", this["name"])
+	assert.Equal(t, "keep $${this}", this["literal"])
+	assert.Equal(t, float64(2), this["count"])
+	assert.Equal(t, map[string]any{"env": "dev"}, this["tags"])
+	assert.Equal(t, "${<<EOT\n${var.id}\nEOT\n}", this["id"])
+	assert.Equal(t, "${upper(var.zone)}", this["zone"])
+	assert.Equal(t, map[string]any{"create_before_destroy": true}, this["lifecycle"])
+}
+
+func TestWriteJsonModule_MergesIntoExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	generate := func(name string) string {
+		generated, err := GenerateForModule(dir, "fake_subnet", Config{SchemaSource: subnetSchemas, ResourceName: name}, nil)
+		require.NoError(t, err)
+		return generated
+	}
+	require.NoError(t, WriteJsonModule(dir, generate(""), DefaultLayout, false))
+	require.NoError(t, WriteJsonModule(dir, generate("frontend"), DefaultLayout, false))
+	require.NoError(t, WriteJsonModule(dir, generate(""), DefaultLayout, false), "generating the same resource again changes nothing")
+
+	var main struct {
+		Resource map[string]map[string]map[string]any `json:"resource"`
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "main.tf.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(content, &main))
+	assert.Equal(t, "${var.subnet_name}", main.Resource["fake_subnet"]["this"]["name"])
+	assert.Equal(t, "${var.frontend_subnet_name}", main.Resource["fake_subnet"]["frontend"]["name"])
+
+	var variables struct {
+		Variable map[string]map[string]any `json:"variable"`
+	}
+	content, err = os.ReadFile(filepath.Join(dir, "variables.tf.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(content, &variables))
+	assert.Equal(t, []string{"frontend_subnet_name", "subnet_name"}, sortedKeys(variables.Variable))
+}
+
+func TestWriteJsonModule_RefreshGeneratedValidations(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf.json"), []byte(`{
+  "variable": {
+    "resource_group_sku": {
+      "type": "string",
+      "default": null,
+      "validation": [
+        {
+          "condition": "${var.resource_group_sku == null ? true : contains([\"Basic\"], var.resource_group_sku)}",
+          "error_message": "The value of `+"`sku`"+` must be one of \"Basic\"."
+        },
+        {
+          "condition": "${var.resource_group_sku != \"Legacy\"}",
+          "error_message": "Legacy is not allowed here."
+        }
+      ]
+    }
+  }
+}
+`), 0600))
+	require.NoError(t, WriteJsonModule(dir, generatedSku, DefaultLayout, false))
+
+	var variables struct {
+		Variable map[string]struct {
+			Validation []map[string]string `json:"validation"`
+		} `json:"variable"`
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "variables.tf.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(content, &variables))
+	var messages []string
+	for _, v := range variables.Variable["resource_group_sku"].Validation {
+		messages = append(messages, v["error_message"])
+	}
+	assert.Equal(t, []string{"Legacy is not allowed here.", "The value of `sku` must be one of \"Basic\", \"Standard\"."}, messages)
+
+	require.NoError(t, WriteJsonModule(dir, generatedSku, DefaultLayout, false))
+	again, err := os.ReadFile(filepath.Join(dir, "variables.tf.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(again))
+}
+
+func TestWriteJsonModule_ConflictWithChangedResource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf.json"), []byte(`{
+  "resource": {
+    "fake_subnet": {
+      "this": {
+        "name": "fixed"
+      }
+    }
+  }
+}
+`), 0600))
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_subnet", Config{SchemaSource: subnetSchemas}, nil))
+	require.NoError(t, err)
+	err = WriteJsonModule(dir, generated, DefaultLayout, false)
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{`resource "fake_subnet" "this" in main.tf.json`}, conflict.Conflicts)
+	_, err = os.Stat(filepath.Join(dir, "variables.tf.json"))
+	assert.True(t, os.IsNotExist(err), "a conflict must not change any file")
+
+	require.NoError(t, WriteJsonModule(dir, generated, DefaultLayout, true))
+	content, err := os.ReadFile(filepath.Join(dir, "main.tf.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "${var.subnet_name}")
+}
@@ -7,10 +7,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// testGetResourceSchema is a test helper that fetches schema dynamically.
+// testSchemaSource serves the static schema registry, so generating resources in tests needs no network access.
+var testSchemaSource = NewMapSchemaSource(resourceSchemas)
+
+// testGetResourceSchema is a test helper that reads schema from the static schema registry, without network access.
 func testGetResourceSchema(t *testing.T, resourceType string) *tfjson.Schema {
 	t.Helper()
-	schema, err := getResourceSchema(resourceType, "", "")
+	schema, err := testSchemaSource.ResourceSchema(resourceType, Config{})
 	require.NoError(t, err)
 	return schema
 }
@@ -0,0 +1,409 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// metaArguments are resource block arguments defined by Terraform, not the provider.
+var metaArguments = map[string]bool{
+	"count":       true,
+	"depends_on":  true,
+	"for_each":    true,
+	"lifecycle":   true,
+	"provider":    true,
+	"provisioner": true,
+	"connection":  true,
+}
+
+// UpgradeReport lists what UpgradeModule changed in, or wants changed by hand in, a module.
+type UpgradeReport struct {
+	ResourceType string
+	// From is the provider version the module was generated with.
+	From             string
+	Version          string
+	AddedVariables   []string
+	AddedArguments   []string
+	RemovedArguments []string
+	// RemovedVariables are the variables wired to removed arguments.
+	RemovedVariables []string
+	NewlyRequired    []string
+	// NestedChanges are changes inside existing nested blocks, which also need the variable types updated
+	// so they are left to be done by hand.
+	NestedChanges []string
+	// Document compares the new version's schema with its documentation, e.g. the arguments it doesn't describe, whose
+	// variables have no description.
+	Document *DocumentReport
+	comments []upgradeComment
+	// previous are the variables and resource arguments generated for From, missing ones were removed on purpose.
+	previousVariables, previousArguments map[string]bool
+}
+
+// upgradeComment is a comment written into file on the line before the token before, the first token of the argument
+// or block it flags.
+type upgradeComment struct {
+	file   *tfFile
+	before *hclwrite.Token
+	text   string
+}
+
+// UpgradeModule brings a module generated by newres in MultipleVariables mode with provider version from up to date
+// with the schema of resourceType in Config.ProviderVersion; from defaults to the version Config.DependencyLockFile
+// locks. Variables and resource wiring are appended for arguments new since from; arguments the new schema no longer
+// has, their variables and variables which became required are flagged with comments next to them. Only the
+// `resource "<resourceType>" "<Config.ResourceName>"` block and the variable blocks it's wired with are modified.
+func UpgradeModule(dir, resourceType, from string, cfg Config) (*UpgradeReport, error) {
+	if cfg.GetMode() != MultipleVariables {
+		return nil, fmt.Errorf("upgrade only supports modules generated in MultipleVariables mode")
+	}
+	from, err := generatedVersion(resourceType, from, cfg)
+	if err != nil {
+		return nil, err
+	}
+	previousCfg := cfg
+	previousCfg.ProviderVersion = from
+	previous, err := GenerateResource(NewResourceGenerateCommand(resourceType, previousCfg, nil))
+	if err != nil {
+		return nil, err
+	}
+	generated, err := GenerateResource(NewResourceGenerateCommand(resourceType, cfg, nil))
+	if err != nil {
+		return nil, err
+	}
+	document, err := CheckDocument(NewResourceGenerateCommand(resourceType, cfg, nil))
+	if err != nil {
+		return nil, err
+	}
+	generatedFile, diag := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	previousFile, diag := hclwrite.ParseConfig([]byte(previous), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return nil, fmt.Errorf("error parsing generated code: %s", diag.Error())
+	}
+	files, err := readTfFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	resource, resourceFile := findBlock(files, "resource", resourceType, cfg.GetResourceName())
+	if resource == nil {
+		if labels := resourceLabels(files, nil, resourceType); len(labels) > 0 {
+			return nil, fmt.Errorf("no `resource %q %q` block found in %s, labels of %s in use: %s; please choose one with --name", resourceType, cfg.GetResourceName(), dir, resourceType, strings.Join(labels, ", "))
+		}
+		return nil, fmt.Errorf("no `resource %q %q` block found in %s", resourceType, cfg.GetResourceName(), dir)
+	}
+	report := &UpgradeReport{
+		ResourceType:      resourceType,
+		From:              from,
+		Version:           cfg.ProviderVersion,
+		Document:          document,
+		previousVariables: make(map[string]bool),
+		previousArguments: make(map[string]bool),
+	}
+	for _, b := range previousFile.Body().Blocks() {
+		switch b.Type() {
+		case "variable":
+			report.previousVariables[b.Labels()[0]] = true
+		case "resource":
+			attrs, blocks := arguments(b.Body())
+			for name := range attrs {
+				report.previousArguments[name] = true
+			}
+			for name := range blocks {
+				report.previousArguments[name] = true
+			}
+		}
+	}
+	variablesFile := moduleFile(dir, files, variablesFileName)
+	for _, b := range generatedFile.Body().Blocks() {
+		switch b.Type() {
+		case "variable":
+			report.upgradeVariable(files, variablesFile, b)
+		case "resource":
+			report.upgradeResource(files, resourceFile, resource.Body(), b.Body())
+		}
+	}
+	if len(report.AddedArguments) > 0 {
+		resourceFile.changed = true
+	}
+	if err = report.writeComments(); err != nil {
+		return nil, err
+	}
+	if err = writeChangedFiles(append(files, variablesFile)); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// generatedVersion returns from, or the version of resourceType's provider locked by Config.DependencyLockFile when
+// from is empty.
+func generatedVersion(resourceType, from string, cfg Config) (string, error) {
+	if from != "" {
+		return from, nil
+	}
+	addr, err := resolveProviderAddress(resourceType, cfg)
+	if err != nil {
+		return "", err
+	}
+	locked, err := loadLockedProvider(cfg.DependencyLockFile, addr)
+	if err != nil {
+		return "", err
+	}
+	if locked == nil {
+		return "", fmt.Errorf("no dependency lock file locks provider %s, please pass the version the module was generated with by --from", addr)
+	}
+	return locked.Version, nil
+}
+
+// upgradeVariable appends generated when the module doesn't have it and it's new since From, and flags the existing
+// one when its argument became required.
+func (r *UpgradeReport) upgradeVariable(files []*tfFile, variablesFile *tfFile, generated *hclwrite.Block) {
+	name := generated.Labels()[0]
+	existing, existingFile := findBlock(files, "variable", name)
+	if existing == nil && r.previousVariables[name] {
+		return
+	}
+	if existing == nil {
+		variablesFile.file.Body().AppendNewline()
+		variablesFile.file.Body().AppendBlock(generated)
+		variablesFile.changed = true
+		r.AddedVariables = append(r.AddedVariables, name)
+		return
+	}
+	required := generated.Body().GetAttribute("default") == nil
+	if defaultValue := existing.Body().GetAttribute("default"); required && defaultValue != nil {
+		r.comment(existingFile, defaultValue.BuildTokens(nil), fmt.Sprintf("newres upgrade: the argument wired to %s is required since %s %s, remove the default", name, r.ResourceType, r.Version))
+		r.NewlyRequired = append(r.NewlyRequired, name)
+	}
+}
+
+// upgradeResource appends the arguments of generated new since From to existing, and flags the arguments of existing
+// generated no longer has along with their variables.
+func (r *UpgradeReport) upgradeResource(files []*tfFile, resourceFile *tfFile, existing, generated *hclwrite.Body) {
+	existingAttrs, existingBlocks := arguments(existing)
+	generatedAttrs, generatedBlocks := arguments(generated)
+	for _, name := range sortedKeys(generatedAttrs) {
+		if _, ok := existingAttrs[name]; ok {
+			continue
+		}
+		if _, ok := existingBlocks[name]; ok || r.previousArguments[name] {
+			continue
+		}
+		existing.SetAttributeRaw(name, generatedAttrs[name].Expr().BuildTokens(nil))
+		r.AddedArguments = append(r.AddedArguments, name)
+	}
+	for _, name := range sortedKeys(generatedBlocks) {
+		if existingBlock, ok := existingBlocks[name]; ok {
+			r.diffNestedBlock(name, blockContent(existingBlock), blockContent(generatedBlocks[name]))
+			continue
+		}
+		if _, ok := existingAttrs[name]; ok || r.previousArguments[name] {
+			continue
+		}
+		existing.AppendNewline()
+		existing.AppendBlock(generatedBlocks[name])
+		r.AddedArguments = append(r.AddedArguments, name)
+	}
+	var removed []string
+	for name := range existingAttrs {
+		if _, ok := generatedAttrs[name]; !ok && !metaArguments[name] {
+			if _, ok = generatedBlocks[name]; !ok {
+				removed = append(removed, name)
+			}
+		}
+	}
+	for name := range existingBlocks {
+		if _, ok := generatedBlocks[name]; !ok && !metaArguments[name] {
+			if _, ok = generatedAttrs[name]; !ok {
+				removed = append(removed, name)
+			}
+		}
+	}
+	sort.Strings(removed)
+	variables := make(map[string]string)
+	for _, name := range removed {
+		var tokens hclwrite.Tokens
+		if attr, ok := existingAttrs[name]; ok {
+			tokens = attr.BuildTokens(nil)
+		} else {
+			tokens = existingBlocks[name].BuildTokens(nil)
+		}
+		r.comment(resourceFile, tokens, fmt.Sprintf("newres upgrade: %s is not supported by %s %s anymore, remove it", name, r.ResourceType, r.Version))
+		r.RemovedArguments = append(r.RemovedArguments, name)
+		for _, v := range referencedVariables(tokens) {
+			variables[v] = name
+		}
+	}
+	for _, name := range sortedKeys(variables) {
+		variable, variableFile := findBlock(files, "variable", name)
+		if variable == nil {
+			continue
+		}
+		r.comment(variableFile, variable.BuildTokens(nil), fmt.Sprintf("newres upgrade: %s is wired to %s, which %s %s doesn't support anymore, remove it", name, variables[name], r.ResourceType, r.Version))
+		r.RemovedVariables = append(r.RemovedVariables, name)
+	}
+}
+
+func (r *UpgradeReport) diffNestedBlock(path string, existing, generated *hclwrite.Body) {
+	if existing == nil || generated == nil {
+		return
+	}
+	existingAttrs, existingBlocks := arguments(existing)
+	generatedAttrs, generatedBlocks := arguments(generated)
+	for _, name := range sortedKeys(generatedAttrs) {
+		if _, ok := existingAttrs[name]; !ok {
+			r.NestedChanges = append(r.NestedChanges, fmt.Sprintf("%s.%s added", path, name))
+		}
+	}
+	for _, name := range sortedKeys(existingAttrs) {
+		if _, ok := generatedAttrs[name]; !ok {
+			r.NestedChanges = append(r.NestedChanges, fmt.Sprintf("%s.%s removed", path, name))
+		}
+	}
+	for _, name := range sortedKeys(generatedBlocks) {
+		existingBlock, ok := existingBlocks[name]
+		if !ok {
+			r.NestedChanges = append(r.NestedChanges, fmt.Sprintf("%s.%s added", path, name))
+			continue
+		}
+		r.diffNestedBlock(path+"."+name, blockContent(existingBlock), blockContent(generatedBlocks[name]))
+	}
+	for _, name := range sortedKeys(existingBlocks) {
+		if _, ok := generatedBlocks[name]; !ok {
+			r.NestedChanges = append(r.NestedChanges, fmt.Sprintf("%s.%s removed", path, name))
+		}
+	}
+}
+
+// String renders the report for humans.
+func (r *UpgradeReport) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Upgraded %s from %s to %s\n", r.ResourceType, r.From, r.Version))
+	sections := []struct {
+		title string
+		items []string
+	}{
+		{"Added variables", r.AddedVariables},
+		{"Added arguments", r.AddedArguments},
+		{"Removed arguments, remove them by hand", r.RemovedArguments},
+		{"Variables of removed arguments, remove them by hand", r.RemovedVariables},
+		{"Variables for newly required arguments, remove their defaults by hand", r.NewlyRequired},
+		{"Changes in existing nested blocks, update them by hand", r.NestedChanges},
+	}
+	changed := false
+	for _, s := range sections {
+		if len(s.items) == 0 {
+			continue
+		}
+		changed = true
+		sb.WriteString(fmt.Sprintf("%s:\n", s.title))
+		for _, item := range s.items {
+			sb.WriteString(fmt.Sprintf("  - %s\n", item))
+		}
+	}
+	if !changed {
+		sb.WriteString("No changes\n")
+	}
+	if r.Document != nil && !r.Document.Empty() {
+		sb.WriteString(r.Document.String())
+	}
+	return sb.String()
+}
+
+// arguments returns the attributes and nested blocks of body, dynamic blocks by the name of the block they generate.
+func arguments(body *hclwrite.Body) (map[string]*hclwrite.Attribute, map[string]*hclwrite.Block) {
+	blocks := make(map[string]*hclwrite.Block)
+	for _, b := range body.Blocks() {
+		name := b.Type()
+		if name == "dynamic" && len(b.Labels()) > 0 {
+			name = b.Labels()[0]
+		}
+		blocks[name] = b
+	}
+	return body.Attributes(), blocks
+}
+
+// blockContent returns the body that holds a nested block's arguments, the `content` block for dynamic blocks.
+func blockContent(b *hclwrite.Block) *hclwrite.Body {
+	if b.Type() != "dynamic" {
+		return b.Body()
+	}
+	content := b.Body().FirstMatchingBlock("content", nil)
+	if content == nil {
+		return nil
+	}
+	return content.Body()
+}
+
+// comment flags the argument or block tokens are of, the comment is written by writeComments.
+func (r *UpgradeReport) comment(file *tfFile, tokens hclwrite.Tokens, text string) {
+	if len(tokens) == 0 {
+		return
+	}
+	// attributes start with the comments before them
+	for _, t := range tokens {
+		if t.Type != hclsyntax.TokenComment {
+			break
+		}
+		if string(t.Bytes) == fmt.Sprintf("# %s\n", text) {
+			return
+		}
+	}
+	r.comments = append(r.comments, upgradeComment{
+		file:   file,
+		before: tokens[0],
+		text:   text,
+	})
+}
+
+// writeComments writes the comments on the lines before the arguments and blocks they flag. hclwrite can only append
+// to a body, so the files are rebuilt from their tokens. A comment already written by an earlier upgrade isn't repeated.
+func (r *UpgradeReport) writeComments() error {
+	comments := make(map[*tfFile]map[*hclwrite.Token]string)
+	var files []*tfFile
+	for _, c := range r.comments {
+		if comments[c.file] == nil {
+			comments[c.file] = make(map[*hclwrite.Token]string)
+			files = append(files, c.file)
+		}
+		comments[c.file][c.before] = c.text
+	}
+	for _, f := range files {
+		var tokens hclwrite.Tokens
+		for _, t := range f.file.BuildTokens(nil) {
+			if text, ok := comments[f][t]; ok {
+				comment := fmt.Sprintf("# %s\n", text)
+				if len(tokens) == 0 || string(tokens[len(tokens)-1].Bytes) != comment {
+					tokens = append(tokens, &hclwrite.Token{
+						Type:         hclsyntax.TokenComment,
+						Bytes:        []byte(comment),
+						SpacesBefore: t.SpacesBefore,
+					})
+					f.changed = true
+				}
+			}
+			tokens = append(tokens, t)
+		}
+		file, diag := hclwrite.ParseConfig(tokens.Bytes(), f.path, hcl.InitialPos)
+		if diag.HasErrors() {
+			return fmt.Errorf("error parsing %s: %s", f.path, diag.Error())
+		}
+		f.file = file
+	}
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var upgradeTestSchemas = versionedSchemaSource{
+	"1.0.0": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name":     {AttributeType: cty.String, Required: true},
+				"size":     {AttributeType: cty.String, Optional: true},
+				"old_flag": {AttributeType: cty.Bool, Optional: true},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"network": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					MaxItems:    1,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"subnet_id": {AttributeType: cty.String, Optional: true},
+						},
+					},
+				},
+			},
+		},
+	},
+	"2.0.0": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name": {AttributeType: cty.String, Required: true},
+				"size": {AttributeType: cty.String, Required: true},
+				"sku":  {AttributeType: cty.String, Optional: true},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"network": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					MaxItems:    1,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"subnet_id": {AttributeType: cty.String, Optional: true},
+							"dns":       {AttributeType: cty.String, Optional: true},
+						},
+					},
+				},
+				"identity": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					MaxItems:    1,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"type": {AttributeType: cty.String, Required: true},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+const handWritten = `
+locals {
+  hand_written = true
+}
+`
+
+// newTestModule generates fake_resource 1.0.0 into dir the same way the newres command does.
+func newTestModule(t *testing.T) string {
+	dir := t.TempDir()
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "1.0.0",
+	}, nil))
+	require.NoError(t, err)
+	f, diag := hclwrite.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	require.False(t, diag.HasErrors())
+	variables, resources := hclwrite.NewEmptyFile(), hclwrite.NewEmptyFile()
+	for _, b := range f.Body().Blocks() {
+		if b.Type() == "variable" {
+			variables.Body().AppendBlock(b)
+			continue
+		}
+		resources.Body().AppendBlock(b)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), variables.Bytes(), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), append(resources.Bytes(), []byte(handWritten)...), 0600))
+	return dir
+}
+
+func TestUpgradeModule(t *testing.T) {
+	dir := newTestModule(t)
+	report, err := UpgradeModule(dir, "fake_resource", "1.0.0", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"resource_identity", "resource_sku"}, sortedKeys(toSet(report.AddedVariables)))
+	assert.Equal(t, []string{"sku", "identity"}, report.AddedArguments)
+	assert.Equal(t, []string{"old_flag"}, report.RemovedArguments)
+	assert.Equal(t, []string{"resource_old_flag"}, report.RemovedVariables)
+	assert.Equal(t, []string{"resource_size"}, report.NewlyRequired)
+	assert.Equal(t, []string{"network.dns added"}, report.NestedChanges)
+
+	main, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(main), "sku = var.resource_sku")
+	assert.Contains(t, string(main), `dynamic "identity"`)
+	assert.Contains(t, string(main), "  # newres upgrade: old_flag is not supported by fake_resource 2.0.0 anymore, remove it\n  old_flag ")
+	assert.True(t, strings.HasSuffix(string(main), handWritten))
+	variables, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(variables), `variable "resource_sku"`)
+	assert.Contains(t, string(variables), "  # newres upgrade: the argument wired to resource_size is required since fake_resource 2.0.0, remove the default\n  default ")
+	assert.Contains(t, string(variables), "# newres upgrade: resource_old_flag is wired to old_flag, which fake_resource 2.0.0 doesn't support anymore, remove it\nvariable \"resource_old_flag\"")
+	assert.Contains(t, report.String(), "Removed arguments, remove them by hand:\n  - old_flag\n")
+	assert.Contains(t, report.String(), "Variables of removed arguments, remove them by hand:\n  - resource_old_flag\n")
+}
+
+func TestUpgradeModule_RerunDoesNotRepeatComments(t *testing.T) {
+	dir := newTestModule(t)
+	cfg := Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+	}
+	_, err := UpgradeModule(dir, "fake_resource", "1.0.0", cfg)
+	require.NoError(t, err)
+	_, err = UpgradeModule(dir, "fake_resource", "1.0.0", cfg)
+	require.NoError(t, err)
+
+	main, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(main), "# newres upgrade:"))
+	variables, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(variables), "# newres upgrade:"))
+}
+
+func TestUpgradeModule_KeepArgumentsRemovedOnPurpose(t *testing.T) {
+	dir := newTestModule(t)
+	main, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	f, diag := hclwrite.ParseConfig(main, "main.tf", hcl.InitialPos)
+	require.False(t, diag.HasErrors())
+	resource := f.Body().FirstMatchingBlock("resource", []string{"fake_resource", "this"}).Body()
+	resource.RemoveBlock(resource.FirstMatchingBlock("dynamic", []string{"network"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), f.Bytes(), 0600))
+	variables, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+	require.NoError(t, err)
+	f, diag = hclwrite.ParseConfig(variables, "variables.tf", hcl.InitialPos)
+	require.False(t, diag.HasErrors())
+	f.Body().RemoveBlock(f.Body().FirstMatchingBlock("variable", []string{"resource_network"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), f.Bytes(), 0600))
+
+	report, err := UpgradeModule(dir, "fake_resource", "1.0.0", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sku", "identity"}, report.AddedArguments)
+	assert.NotContains(t, report.AddedVariables, "resource_network")
+	main, err = os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(main), `dynamic "network"`)
+}
+
+func TestUpgradeModule_FromLockedVersion(t *testing.T) {
+	dir := newTestModule(t)
+	lockFile := filepath.Join(dir, ".terraform.lock.hcl")
+	require.NoError(t, os.WriteFile(lockFile, []byte(`provider "registry.terraform.io/hashicorp/fake" {
+  version = "1.0.0"
+}
+`), 0600))
+	cfg := Config{
+		SchemaSource:       upgradeTestSchemas,
+		ProviderNamespace:  "hashicorp",
+		ProviderVersion:    "2.0.0",
+		DependencyLockFile: lockFile,
+	}
+	report, err := UpgradeModule(dir, "fake_resource", "", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", report.From)
+
+	cfg.DependencyLockFile = ""
+	_, err = UpgradeModule(dir, "fake_resource", "", cfg)
+	assert.ErrorContains(t, err, "--from")
+}
+
+func TestUpgradeModule_NoChanges(t *testing.T) {
+	dir := newTestModule(t)
+	before, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	report, err := UpgradeModule(dir, "fake_resource", "1.0.0", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "1.0.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Upgraded fake_resource from 1.0.0 to 1.0.0\nNo changes\n", report.String())
+	after, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestUpgradeModule_ResourceNotFound(t *testing.T) {
+	_, err := UpgradeModule(t.TempDir(), "fake_resource", "1.0.0", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+	})
+	assert.Error(t, err)
+}
+
+func TestUpgradeModule_ReportExistingLabels(t *testing.T) {
+	dir := newTestModule(t)
+	_, err := UpgradeModule(dir, "fake_resource", "1.0.0", Config{
+		SchemaSource:    upgradeTestSchemas,
+		ProviderVersion: "2.0.0",
+		ResourceName:    "frontend",
+	})
+	assert.ErrorContains(t, err, "labels of fake_resource in use: this")
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, i := range items {
+		set[i] = true
+	}
+	return set
+}
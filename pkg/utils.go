@@ -74,6 +74,9 @@ func ctyTypeToVariableTypeString(t cty.Type) string {
 
 		for _, pair := range attributes {
 			fieldType := ctyTypeToVariableTypeString(pair.t)
+			if t.AttributeOptional(pair.name) {
+				fieldType = fmt.Sprintf("optional(%s)", fieldType)
+			}
 			sb.WriteString(fmt.Sprintf("%s = %s\n", pair.name, fieldType))
 		}
 		return fmt.Sprintf(`object({
@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// variableValidation is a `validation` block generated from what a document says about an argument.
+type variableValidation struct {
+	condition    string
+	errorMessage string
+}
+
+// generatedValidationMessageRegexes match the error messages of the validations newres generates, by
+// enumValidation and constraintValidations, telling them from validations written by hand.
+var generatedValidationMessageRegexes = []*regexp.Regexp{
+	regexp.MustCompile("^The value of `[^`]+` must be one of .+\\.$"),
+	regexp.MustCompile("^`[^`]+` conflicts with `[^`]+`, only one of them can be set\\.$"),
+	regexp.MustCompile("^(Exactly|At most|At least) one of `[^`]+`(, `[^`]+`)* must be set\\.$"),
+	regexp.MustCompile("^`[^`]+` is required when `[^`]+` is .+\\.$"),
+}
+
+// appendTo appends the validation block to variable block vb.
+func (v variableValidation) appendTo(vb *hclwrite.Block) {
+	validation := vb.Body().AppendNewBlock("validation", nil)
+	validation.Body().SetAttributeRaw("condition", newTokens().ident(v.condition, 1).Tokens)
+	validation.Body().SetAttributeValue("error_message", cty.StringVal(v.errorMessage))
+}
+
+// generatedValidationMessage reports whether a validation with errorMessage was generated by newres.
+func generatedValidationMessage(errorMessage string) bool {
+	for _, r := range generatedValidationMessageRegexes {
+		if r.MatchString(errorMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedValidation reports whether the `validation` block b was generated by newres.
+func generatedValidation(b *hclwrite.Block) bool {
+	attr := b.Body().GetAttribute("error_message")
+	if attr == nil {
+		return false
+	}
+	expr, diag := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return false
+	}
+	v, diag := expr.Value(nil)
+	if diag.HasErrors() || !v.Type().Equals(cty.String) || !v.IsKnown() || v.IsNull() {
+		return false
+	}
+	return generatedValidationMessage(v.AsString())
+}
+
+// enumValidation returns the validation accepting only the documented possible values of the argument at path, of
+// type t, referenced by ref. Only strings, numbers and collections of them are validated.
+func enumValidation(descriptions map[string]argumentDescription, path string, t cty.Type, ref string) (variableValidation, bool) {
+	d, ok := describe(descriptions, path)
+	if !ok || len(d.possibleValues) == 0 {
+		return variableValidation{}, false
+	}
+	elementType := t
+	if t.IsListType() || t.IsSetType() {
+		elementType = t.ElementType()
+	}
+	if elementType != cty.String && elementType != cty.Number {
+		return variableValidation{}, false
+	}
+	var values []cty.Value
+	var literals []string
+	for _, pv := range d.possibleValues {
+		v, err := convert.Convert(cty.StringVal(pv), elementType)
+		if err != nil {
+			return variableValidation{}, false
+		}
+		values = append(values, v)
+		literals = append(literals, strings.TrimSpace(string(hclwrite.TokensForValue(v).Bytes())))
+	}
+	list := strings.TrimSpace(string(hclwrite.TokensForValue(cty.ListVal(values)).Bytes()))
+	condition := fmt.Sprintf("contains(%s, %s)", list, ref)
+	if elementType != t {
+		condition = fmt.Sprintf("alltrue([for value in %s : contains(%s, value)])", ref, list)
+	}
+	return variableValidation{
+		condition:    fmt.Sprintf("%s == null ? true : %s", ref, condition),
+		errorMessage: fmt.Sprintf("The value of `%s` must be one of %s.", path, strings.Join(literals, ", ")),
+	}, true
+}
+
+// attributeValidations returns the validations of attribute a of b, ref returns the expression of an argument of b.
+func attributeValidations(b block, a *attribute, ref func(string) string, descriptions map[string]argumentDescription) []variableValidation {
+	var validations []variableValidation
+	if v, ok := enumValidation(descriptions, documentPath(b, a.name), a.AttributeType, ref(a.name)); ok {
+		validations = append(validations, v)
+	}
+	return append(validations, constraintValidations(b, a.name, ref, descriptions)...)
+}
+
+// argumentValidations returns the validations of the arguments of b, nested blocks included, ref returns the
+// expression of an argument of b.
+func argumentValidations(b block, ref func(string) string, descriptions map[string]argumentDescription) []variableValidation {
+	var validations []variableValidation
+	for _, a := range b.attributes() {
+		if !a.computedOnly() && !a.skipAttribute() {
+			validations = append(validations, attributeValidations(b, a, ref, descriptions)...)
+		}
+	}
+	for _, nb := range b.nestedBlocks() {
+		if nb.blockReadOnly() {
+			continue
+		}
+		validations = append(validations, constraintValidations(b, nb.name, ref, descriptions)...)
+		validations = append(validations, nestedBlockValidations(nb, ref(nb.name), descriptions)...)
+	}
+	return validations
+}
+
+// nestedBlockValidations returns the validations of the arguments of nb, ref is nb's object or collection of objects,
+// which can be null.
+func nestedBlockValidations(nb *nestedBlock, ref string, descriptions map[string]argumentDescription) []variableValidation {
+	item := ref
+	if !singleObject(nb) {
+		item = nb.name
+	}
+	validations := argumentValidations(nb, func(name string) string {
+		return fmt.Sprintf("%s.%s", item, name)
+	}, descriptions)
+	for i, v := range validations {
+		condition := v.condition
+		if !singleObject(nb) {
+			condition = fmt.Sprintf("alltrue([for %s in %s : %s])", nb.name, ref, condition)
+		}
+		validations[i].condition = fmt.Sprintf("%s == null ? true : %s", ref, condition)
+	}
+	return validations
+}
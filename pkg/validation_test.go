@@ -0,0 +1,234 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var enumTestSchemas = versionedSchemaSource{
+	"1.0.0": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name":  {AttributeType: cty.String, Required: true},
+				"sku":   {AttributeType: cty.String, Optional: true},
+				"zones": {AttributeType: cty.List(cty.String), Optional: true},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"probe": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"transport": {AttributeType: cty.String, Required: true},
+							"port":      {AttributeType: cty.Number, Optional: true},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+const enumTestDocument = `## Arguments Reference
+
+* ` + "`name`" + ` - (Required) The name.
+
+* ` + "`sku`" + ` - (Optional) The SKU. Possible values are ` + "`Standard`" + ` and ` + "`Premium`" + `.
+
+* ` + "`zones`" + ` - (Optional) The zones. Possible values are ` + "`1`, `2` and `3`" + `.
+
+* ` + "`probe`" + ` - (Optional) One or more probe blocks.
+
+A ` + "`probe`" + ` block supports the following:
+
+* ` + "`transport`" + ` - (Required) Type of probe. Possible values are ` + "`TCP`, `HTTP`, and `HTTPS`" + `.
+
+* ` + "`port`" + ` - (Optional) The port. Possible values are between ` + "`1`" + ` and ` + "`65535`" + `.
+
+## Attributes Reference
+`
+
+// generatedValidations generates fake_resource with document and returns the conditions of the validations of each
+// variable.
+func generatedValidations(t *testing.T, schemas versionedSchemaSource, document string, mode GenerateMode) map[string][]string {
+	return generatedValidationsWithConfig(t, schemas, document, Config{Mode: mode})
+}
+
+func generatedValidationsWithConfig(t *testing.T, schemas versionedSchemaSource, document string, cfg Config) map[string][]string {
+	previous := content
+	content = func(string, Config) (string, error) {
+		return document, nil
+	}
+	t.Cleanup(func() {
+		content = previous
+	})
+	cfg.SchemaSource = schemas
+	cfg.ProviderVersion = "1.0.0"
+	generated, err := GenerateResource(NewResourceGenerateCommand("fake_resource", cfg, nil))
+	require.NoError(t, err)
+	file, diag := hclsyntax.ParseConfig([]byte(generated), "", hcl.InitialPos)
+	require.False(t, diag.HasErrors(), diag.Error())
+	conditions := make(map[string][]string)
+	for _, b := range file.Body.(*hclsyntax.Body).Blocks {
+		if b.Type != "variable" {
+			continue
+		}
+		for _, v := range b.Body.Blocks {
+			require.Equal(t, "validation", v.Type)
+			condition := v.Body.Attributes["condition"].Expr
+			conditions[b.Labels[0]] = append(conditions[b.Labels[0]], string(condition.Range().SliceBytes(file.Bytes)))
+			require.Contains(t, v.Body.Attributes, "error_message")
+			message, diag := v.Body.Attributes["error_message"].Expr.Value(nil)
+			require.False(t, diag.HasErrors(), diag.Error())
+			assert.True(t, generatedValidationMessage(message.AsString()), "re-generation doesn't recognise %q as generated", message.AsString())
+		}
+	}
+	return conditions
+}
+
+func TestGenerateResource_EnumValidation(t *testing.T) {
+	assert.Equal(t, map[string][]string{
+		"resource_sku":   {`var.resource_sku == null ? true : contains(["Standard", "Premium"], var.resource_sku)`},
+		"resource_zones": {`var.resource_zones == null ? true : alltrue([for value in var.resource_zones : contains(["1", "2", "3"], value)])`},
+		"resource_probe": {`var.resource_probe == null ? true : alltrue([for probe in var.resource_probe : probe.transport == null ? true : contains(["TCP", "HTTP", "HTTPS"], probe.transport)])`},
+	}, generatedValidations(t, enumTestSchemas, enumTestDocument, MultipleVariables))
+}
+
+func TestGenerateResource_EnumValidationInObjectVariable(t *testing.T) {
+	assert.Equal(t, map[string][]string{
+		"resource": {
+			`var.resource.sku == null ? true : contains(["Standard", "Premium"], var.resource.sku)`,
+			`var.resource.zones == null ? true : alltrue([for value in var.resource.zones : contains(["1", "2", "3"], value)])`,
+			`var.resource.probe == null ? true : alltrue([for probe in var.resource.probe : probe.transport == null ? true : contains(["TCP", "HTTP", "HTTPS"], probe.transport)])`,
+		},
+	}, generatedValidations(t, enumTestSchemas, enumTestDocument, UniVariable))
+}
+
+func TestEnumValidation_ErrorMessage(t *testing.T) {
+	descriptions := withDocumentedValues(map[string]argumentDescription{
+		"probe.port": {name: "port", desc: "(Optional) The port. Possible values are `80` and `443`."},
+		"sku":        {name: "sku", desc: "(Optional) The SKU. Possible values are `Standard`."},
+	})
+	v, ok := enumValidation(descriptions, "probe.port", cty.Number, "probe.port")
+	require.True(t, ok)
+	assert.Equal(t, "The value of `probe.port` must be one of 80, 443.", v.errorMessage)
+	v, ok = enumValidation(descriptions, "sku", cty.String, "var.sku")
+	require.True(t, ok)
+	assert.Equal(t, "The value of `sku` must be one of \"Standard\".", v.errorMessage)
+	_, ok = enumValidation(descriptions, "sku", cty.Bool, "var.sku")
+	assert.False(t, ok)
+}
+
+var constraintTestSchemas = versionedSchemaSource{
+	"1.0.0": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"os_type":        {AttributeType: cty.String, Required: true},
+				"admin_password": {AttributeType: cty.String, Optional: true},
+				"ssh_key_id":     {AttributeType: cty.String, Optional: true},
+				"subnet_id":      {AttributeType: cty.String, Optional: true},
+				"vnet_id":        {AttributeType: cty.String, Optional: true},
+				"private":        {AttributeType: cty.Bool, Optional: true},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"probe": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"path": {AttributeType: cty.String, Optional: true},
+							"port": {AttributeType: cty.Number, Optional: true},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+const constraintTestDocument = `## Arguments Reference
+
+* ` + "`os_type`" + ` - (Required) The OS type.
+
+* ` + "`admin_password`" + ` - (Optional) The admin password. Required when ` + "`os_type` is `Windows`" + `. Conflicts with ` + "`ssh_key_id`" + `.
+
+* ` + "`ssh_key_id`" + ` - (Optional) The SSH key. Conflicts with ` + "`admin_password` and `unknown_argument`" + `.
+
+* ` + "`subnet_id`" + ` - (Optional) The subnet. Exactly one of ` + "`subnet_id` or `vnet_id`" + ` must be specified.
+
+* ` + "`vnet_id`" + ` - (Optional) The virtual network. Exactly one of ` + "`subnet_id` or `vnet_id`" + ` must be specified.
+
+* ` + "`private`" + ` - (Optional) Whether the resource is private. Defaults to ` + "`false`" + `. Conflicts with ` + "`admin_password`" + `.
+
+* ` + "`probe`" + ` - (Optional) One or more probe blocks.
+
+A ` + "`probe`" + ` block supports the following:
+
+* ` + "`path`" + ` - (Optional) The path. Only one of ` + "`path` or `port`" + ` may be specified.
+
+* ` + "`port`" + ` - (Optional) The port.
+
+## Attributes Reference
+`
+
+func TestParseArgumentConstraints(t *testing.T) {
+	c := parseArgumentConstraints("admin_password", "(Optional) The admin password. Required when `os_type` is set to `Windows`. Cannot be used together with `ssh_key_id` or `ssh_key`.")
+	assert.Equal(t, []string{"ssh_key_id", "ssh_key"}, c.conflictsWith)
+	assert.Equal(t, &requiredWhen{argument: "os_type", value: "Windows"}, c.requiredWhen)
+	assert.Nil(t, c.oneOf)
+
+	c = parseArgumentConstraints("subnet_id", "(Optional) The subnet. Exactly one of `vnet_id` or `subnet_id` must be specified.")
+	assert.Equal(t, &argumentGroup{arguments: []string{"subnet_id", "vnet_id"}, operator: "=="}, c.oneOf)
+	c = parseArgumentConstraints("path", "(Optional) The path. Only one of `port` may be specified.")
+	assert.Equal(t, &argumentGroup{arguments: []string{"path", "port"}, operator: "<="}, c.oneOf)
+	c = parseArgumentConstraints("a", "(Optional) A. One of `a`, `b` or `c` must be set.")
+	assert.Equal(t, &argumentGroup{arguments: []string{"a", "b", "c"}, operator: ">="}, c.oneOf)
+
+	assert.Equal(t, argumentConstraints{}, parseArgumentConstraints("name", "(Required) The name. Changing this forces a new resource to be created."))
+}
+
+func TestGenerateResource_ConstraintValidation(t *testing.T) {
+	assert.Equal(t, map[string][]string{
+		"resource_admin_password": {
+			`var.resource_admin_password == null || var.resource_ssh_key_id == null`,
+			`var.resource_os_type != "Windows" || var.resource_admin_password != null`,
+		},
+		"resource_private":   {`var.resource_private == false || var.resource_admin_password == null`},
+		"resource_subnet_id": {`length([for argument in [var.resource_subnet_id, var.resource_vnet_id] : argument if argument != null]) == 1`},
+		"resource_probe":     {`var.resource_probe == null ? true : alltrue([for probe in var.resource_probe : length([for argument in [probe.path, probe.port] : argument if argument != null]) <= 1])`},
+	}, generatedValidations(t, constraintTestSchemas, constraintTestDocument, MultipleVariables))
+}
+
+func TestGenerateResource_NoCrossVariableValidations(t *testing.T) {
+	assert.Equal(t, map[string][]string{
+		"resource_probe": {`var.resource_probe == null ? true : alltrue([for probe in var.resource_probe : length([for argument in [probe.path, probe.port] : argument if argument != null]) <= 1])`},
+	}, generatedValidationsWithConfig(t, constraintTestSchemas, constraintTestDocument, Config{NoCrossVariableValidations: true}))
+}
+
+func TestGenerateResource_ConstraintValidationWithNullDefaults(t *testing.T) {
+	assert.Contains(t, generatedValidationsWithConfig(t, constraintTestSchemas, constraintTestDocument, Config{NullDefaults: true})["resource_private"],
+		`var.resource_private == null || var.resource_admin_password == null`)
+}
+
+func TestGenerateResource_OneOfValidationWithDefault(t *testing.T) {
+	document := strings.Replace(constraintTestDocument, "(Optional) The subnet.", "(Optional) The subnet. Defaults to `default`.", 1)
+	assert.Equal(t, []string{`length([for set in [var.resource_subnet_id != "default", var.resource_vnet_id != null] : set if set]) == 1`},
+		generatedValidations(t, constraintTestSchemas, document, MultipleVariables)["resource_subnet_id"])
+}
+
+func TestGenerateResource_ConstraintValidationInObjectVariable(t *testing.T) {
+	assert.Equal(t, map[string][]string{
+		"resource": {
+			`var.resource.admin_password == null || var.resource.ssh_key_id == null`,
+			`var.resource.os_type != "Windows" || var.resource.admin_password != null`,
+			`var.resource.private == false || var.resource.admin_password == null`,
+			`length([for argument in [var.resource.subnet_id, var.resource.vnet_id] : argument if argument != null]) == 1`,
+			`var.resource.probe == null ? true : alltrue([for probe in var.resource.probe : length([for argument in [probe.path, probe.port] : argument if argument != null]) <= 1])`,
+		},
+	}, generatedValidations(t, constraintTestSchemas, constraintTestDocument, UniVariable))
+}
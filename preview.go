@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	autofix "github.com/lonegunmanb/avmfix/pkg"
+	"github.com/lonegunmanb/newres/v3/pkg"
+)
+
+// splitGenerated splits generated code into the files of layout, in the hcl or json syntax.
+func splitGenerated(generated string, layout pkg.FileLayout, format string) ([]pkg.GeneratedFile, error) {
+	if format == "json" {
+		return pkg.SplitGeneratedJson(generated, layout)
+	}
+	return pkg.SplitGeneratedCode(generated, layout)
+}
+
+// writeModule writes generated code into the module in dir, merged into the existing files. HCL files are autofixed
+// afterwards.
+func writeModule(dir, generated string, layout pkg.FileLayout, format string, force bool) error {
+	if format == "json" {
+		return pkg.WriteJsonModule(dir, generated, layout, force)
+	}
+	if err := pkg.MergeIntoModule(dir, generated, layout, force); err != nil {
+		return err
+	}
+	if err := autofix.DirectoryAutoFix(dir); err != nil {
+		return fmt.Errorf("autofix: %w", err)
+	}
+	return nil
+}
+
+// printGeneratedFiles prints generated code split by the files it would be written to. HCL files are each printed under
+// a comment header so the output is still valid HCL, JSON files are printed as one JSON object keyed by file name.
+func printGeneratedFiles(generated string, layout pkg.FileLayout, format string) error {
+	files, err := splitGenerated(generated, layout, format)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return printJsonFiles(files)
+	}
+	for i, f := range files {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("# ---- %s ----\n", f.Name)
+		fmt.Print(string(f.Content))
+	}
+	return nil
+}
+
+func printJsonFiles(files []pkg.GeneratedFile) error {
+	var doc bytes.Buffer
+	doc.WriteString("{")
+	for i, f := range files {
+		if i > 0 {
+			doc.WriteString(",")
+		}
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return err
+		}
+		doc.Write(name)
+		doc.WriteString(":")
+		if err = json.Compact(&doc, f.Content); err != nil {
+			return fmt.Errorf("invalid generated %s: %w", f.Name, err)
+		}
+	}
+	doc.WriteString("}")
+	var out bytes.Buffer
+	if err := json.Indent(&out, doc.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	fmt.Println(out.String())
+	return nil
+}
+
+// previewChanges writes generated code into a copy of the module in dir, the same way a real run would, and prints
+// a unified diff of the result against dir.
+func previewChanges(dir, generated string, layout pkg.FileLayout, format string, force bool) error {
+	tmp, err := os.MkdirTemp("", "newres-dry-run")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+	if err = pkg.CopyModuleFiles(dir, tmp); err != nil {
+		return err
+	}
+	if err = writeModule(tmp, generated, layout, format, force); err != nil {
+		return err
+	}
+	diff, err := pkg.DiffModules(dir, tmp)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		fmt.Println("No changes")
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
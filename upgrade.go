@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/lonegunmanb/newres/v3/pkg"
+)
+
+// runUpgrade implements `newres upgrade`, updating a module generated in MultipleVariables mode to a newer provider
+// version.
+func runUpgrade(args []string) error {
+	flags := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	dir := flags.String("dir", "", "Directory of the module to upgrade (required)")
+	resourceType := flags.String("r", "", "Resource type to upgrade (required)")
+	flags.StringVar(resourceType, "resource-type", "", "")
+	to := flags.String("to", "", "Provider version to upgrade to (required)")
+	from := flags.String("from", "", "Provider version the module was generated with (optional); defaults to the version locked in the module's .terraform.lock.hcl")
+	delimiter := flags.String("delimiter", "EOT", "Heredoc delimiter (optional)")
+	variablePrefix := flags.String("variable-prefix", "", "Variable name prefix the module was generated with (optional)")
+	name := flags.String("name", "", "Label of the resource block to upgrade, the --name the module was generated with; defaults to `this`")
+	providerNamespace := flags.String("provider-namespace", "", "Provider namespace (e.g., hashicorp, Azure, aliyun); discovered from the Terraform Registry if not set")
+	providerSource := flags.String("provider-source", "", "Provider source address (e.g., app.terraform.io/acme/internalcloud); overrides --provider-namespace")
+	reportFile := flags.String("report", "", "Also write the change report to this file (optional)")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(os.Stderr, "Usage: newres upgrade -dir [DIRECTORY] -r RESOURCE_TYPE --to VERSION [--from VERSION] [--name NAME] [--variable-prefix PREFIX] [--report FILE]")
+		_, _ = fmt.Fprintln(os.Stderr, "Only modules generated in the default MultipleVariables mode are supported, not UniVariable ones.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if *dir == "" || *resourceType == "" || *to == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *name != "" && !hclsyntax.ValidIdentifier(*name) {
+		return fmt.Errorf("--name %q is not a valid resource name", *name)
+	}
+	variablePrefixProvided := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "variable-prefix" {
+			variablePrefixProvided = true
+		}
+	})
+
+	cfg := pkg.Config{
+		Delimiter:          *delimiter,
+		Mode:               pkg.MultipleVariables,
+		VariablePrefix:     *variablePrefix,
+		VariablePrefixSet:  variablePrefixProvided,
+		ProviderNamespace:  *providerNamespace,
+		ProviderSource:     *providerSource,
+		ProviderVersion:    *to,
+		ResourceName:       *name,
+		DependencyLockFile: filepath.Join(*dir, ".terraform.lock.hcl"),
+	}
+	var report *pkg.UpgradeReport
+	err := withChosenNamespace(&cfg, func() (err error) {
+		report, err = pkg.UpgradeModule(*dir, *resourceType, *from, cfg)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(report.String())
+	if *reportFile == "" {
+		return nil
+	}
+	if err = os.WriteFile(*reportFile, []byte(report.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *reportFile, err)
+	}
+	return nil
+}